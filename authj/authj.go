@@ -3,9 +3,12 @@ package authj
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
+
+	"github.com/things-go/gin-contrib/traceid"
 )
 
 // contextKey is a value for use with context.WithValue. It's used as
@@ -18,6 +21,11 @@ type Config struct {
 	forbiddenFallback  func(*gin.Context)
 	skipAuthentication func(*gin.Context) bool
 	subject            func(*gin.Context) string
+	request            RequestBuilder
+	cache              *DecisionCache
+	auditor            Auditor
+	auditAllowed       func(*gin.Context) bool
+	shadow             *Shadow
 }
 
 // Option config option
@@ -53,6 +61,60 @@ func WithSubject(fn func(*gin.Context) string) Option {
 	}
 }
 
+// WithRequest set the builder of the enforcement arguments of the requests,
+// such as (sub, dom, obj, act) of the RBAC with domains model, see NewRequest.
+// default: DefaultRequest, which is (subject, path, method).
+func WithRequest(fn RequestBuilder) Option {
+	return func(cfg *Config) {
+		if fn != nil {
+			cfg.request = fn
+		}
+	}
+}
+
+// WithDecisionCache set the cache of the decisions, it must be cleared when the policy changes,
+// see NewCacheWatcher.
+// default: no cache
+func WithDecisionCache(d *DecisionCache) Option {
+	return func(cfg *Config) {
+		if d != nil {
+			cfg.cache = d
+		}
+	}
+}
+
+// WithAuditor set the auditor of the decisions, the denied and failed decisions are always audited,
+// the allowed ones are audited if WithAuditAllowed returns true.
+// default: no audit
+func WithAuditor(a Auditor) Option {
+	return func(cfg *Config) {
+		if a != nil {
+			cfg.auditor = a
+		}
+	}
+}
+
+// WithAuditAllowed set whether the allowed decision is audited when it is return true, such as the sensitive routes.
+// default: always false
+func WithAuditAllowed(fn func(*gin.Context) bool) Option {
+	return func(cfg *Config) {
+		if fn != nil {
+			cfg.auditAllowed = fn
+		}
+	}
+}
+
+// WithShadow set the shadow which evaluates the candidate enforcer in dry-run,
+// the DecisionCache only caches the decisions of the enforcer of Authorizer.
+// default: no shadow
+func WithShadow(s *Shadow) Option {
+	return func(cfg *Config) {
+		if s != nil {
+			cfg.shadow = s
+		}
+	}
+}
+
 // WithSkipAuthentication set the skip approve when it is return true.
 // Default: always false
 func WithSkipAuthentication(fn func(*gin.Context) bool) Option {
@@ -67,28 +129,65 @@ func WithSkipAuthentication(fn func(*gin.Context) bool) Option {
 // uses a Casbin enforcer, and Subject as subject.
 func Authorizer(e casbin.IEnforcer, opts ...Option) gin.HandlerFunc {
 	cfg := Config{
-		func(c *gin.Context, err error) {
+		errFallback: func(c *gin.Context, err error) {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
 				"msg":  "Permission validation errors occur!",
 			})
 		},
-		func(c *gin.Context) {
+		forbiddenFallback: func(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code": http.StatusForbidden,
 				"msg":  "Permission denied!",
 			})
 		},
-		func(c *gin.Context) bool { return false },
-		Subject,
+		skipAuthentication: func(c *gin.Context) bool { return false },
+		subject:            Subject,
+		request:            DefaultRequest,
+		auditAllowed:       func(c *gin.Context) bool { return false },
 	}
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 	return func(c *gin.Context) {
 		if !cfg.skipAuthentication(c) {
-			// checks the subject,path,method permission combination from the request.
-			allowed, err := e.Enforce(cfg.subject(c), c.Request.URL.Path, c.Request.Method)
+			start := time.Now()
+			subject := cfg.subject(c)
+			// checks the permission combination from the request, default subject,path,method.
+			rvals, err := cfg.request(c, subject)
+			live, shadow, promoted := e, casbin.IEnforcer(nil), false
+			if cfg.shadow != nil {
+				live, shadow, promoted = cfg.shadow.enforcers(e)
+			}
+			allowed, explain := false, []string(nil)
+			if err == nil {
+				allowed, explain, err = cfg.enforce(live, rvals, !promoted)
+			}
+			if cfg.auditor != nil && (err != nil || !allowed || cfg.auditAllowed(c)) {
+				cfg.auditor.Audit(c, &AuditRecord{
+					Subject: subject,
+					Args:    rvals,
+					Allowed: allowed,
+					Err:     err,
+					Explain: explain,
+					Latency: time.Since(start),
+					TraceId: traceid.GetTraceId(c),
+				})
+			}
+			if shadow != nil && err == nil {
+				shadowAllowed, shadowErr := shadow.Enforce(rvals...)
+				if shadowErr != nil || shadowAllowed != allowed {
+					cfg.shadow.reporter.Report(c, &ShadowDiff{
+						Subject:       subject,
+						Args:          rvals,
+						Allowed:       allowed,
+						ShadowAllowed: shadowAllowed,
+						ShadowErr:     shadowErr,
+						Promoted:      promoted,
+						TraceId:       traceid.GetTraceId(c),
+					})
+				}
+			}
 			if err != nil {
 				c.Abort()
 				cfg.errFallback(c, err)
@@ -104,6 +203,14 @@ func Authorizer(e casbin.IEnforcer, opts ...Option) gin.HandlerFunc {
 	}
 }
 
+// enforce returns the decision, and the matched policy rule if it is audited.
+func (cfg *Config) enforce(e casbin.IEnforcer, rvals []any, cacheable bool) (bool, []string, error) {
+	if cfg.cache != nil && cacheable {
+		return cfg.cache.enforce(e, rvals, cfg.auditor != nil)
+	}
+	return enforce(e, rvals, cfg.auditor != nil)
+}
+
 // Subject returns the value associated with this context for subjectCtxKey,
 func Subject(c *gin.Context) string {
 	val, _ := c.Request.Context().Value(ctxAuthKey{}).(string)
@@ -0,0 +1,37 @@
+package authj
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditRecord the record of an authorization decision.
+type AuditRecord struct {
+	// Subject the subject of the request.
+	Subject string
+	// Args the enforcement arguments, nil if the RequestBuilder failed.
+	Args []any
+	// Allowed the decision.
+	Allowed bool
+	// Err the error of building the request or enforcing.
+	Err error
+	// Explain the matched policy rule returned by EnforceEx, empty if no rule matched,
+	// the decision from the DecisionCache has the same explanation as the enforcer.
+	Explain []string
+	// Latency the time of building the request and enforcing.
+	Latency time.Duration
+	// TraceId the trace id of the request, see traceid.TraceId.
+	TraceId string
+}
+
+// Auditor audits the authorization decisions, such as logging them.
+type Auditor interface {
+	Audit(c *gin.Context, r *AuditRecord)
+}
+
+// AuditorFunc is an adapter to allow the use of ordinary functions as Auditor.
+type AuditorFunc func(c *gin.Context, r *AuditRecord)
+
+// Audit calls f(c, r).
+func (f AuditorFunc) Audit(c *gin.Context, r *AuditRecord) { f(c, r) }
@@ -0,0 +1,113 @@
+package authj
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/traceid"
+)
+
+func TestWithAuditor(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+
+	var records []*AuditRecord
+	router := gin.New()
+	router.Use(traceid.TraceId())
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, "cathy")
+	})
+	router.Use(Authorizer(e,
+		WithAuditor(AuditorFunc(func(c *gin.Context, r *AuditRecord) {
+			records = append(records, r)
+		})),
+		WithAuditAllowed(func(c *gin.Context) bool {
+			return c.Request.Method == http.MethodDelete
+		}),
+	))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// allowed but not sensitive.
+	testAuthjRequest(t, router, "cathy", "/dataset1/item", http.MethodGet, http.StatusOK)
+	require.Empty(t, records)
+
+	testAuthjRequest(t, router, "cathy", "/dataset1/item", http.MethodDelete, http.StatusOK)
+	require.Len(t, records, 1)
+	require.True(t, records[0].Allowed)
+	require.Equal(t, "cathy", records[0].Subject)
+	require.Equal(t, []any{"cathy", "/dataset1/item", http.MethodDelete}, records[0].Args)
+	require.Equal(t, []string{"dataset1_admin", "/dataset1/*", "*"}, records[0].Explain)
+	require.NotEmpty(t, records[0].TraceId)
+	require.Positive(t, records[0].Latency)
+
+	testAuthjRequest(t, router, "cathy", "/dataset2/item", http.MethodGet, http.StatusForbidden)
+	require.Len(t, records, 2)
+	require.False(t, records[1].Allowed)
+	require.Empty(t, records[1].Explain)
+	require.NoError(t, records[1].Err)
+}
+
+func TestWithAuditor_DecisionCache(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	d := NewDecisionCache(time.Minute, 0)
+	// cached without the explanation.
+	_, err = d.Enforce(e, "cathy", "/dataset1/item", http.MethodDelete)
+	require.NoError(t, err)
+
+	var records []*AuditRecord
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, "cathy")
+	})
+	router.Use(Authorizer(e,
+		WithDecisionCache(d),
+		WithAuditor(AuditorFunc(func(c *gin.Context, r *AuditRecord) {
+			records = append(records, r)
+		})),
+		WithAuditAllowed(func(c *gin.Context) bool { return true }),
+	))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for range 2 {
+		testAuthjRequest(t, router, "cathy", "/dataset1/item", http.MethodDelete, http.StatusOK)
+	}
+	require.Len(t, records, 2)
+	for _, r := range records {
+		require.Equal(t, []string{"dataset1_admin", "/dataset1/*", "*"}, r.Explain)
+	}
+}
+
+func TestWithAuditor_Error(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+
+	var record *AuditRecord
+	router := gin.New()
+	router.Use(Authorizer(e,
+		WithRequest(func(*gin.Context, string) ([]any, error) {
+			return nil, errors.New("missing tenant")
+		}),
+		WithAuditor(AuditorFunc(func(c *gin.Context, r *AuditRecord) {
+			record = r
+		})),
+	))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodGet, http.StatusInternalServerError)
+	require.NotNil(t, record)
+	require.EqualError(t, record.Err, "missing tenant")
+	require.False(t, record.Allowed)
+}
@@ -0,0 +1,211 @@
+package authj
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/patrickmn/go-cache"
+)
+
+// DefaultDecisionCacheSize default max number of the decisions of DecisionCache.
+const DefaultDecisionCacheSize = 10000
+
+// DecisionCache caches the decisions of the enforcer keyed by the enforcement arguments,
+// together with the explanation of the decision if it is audited, the errors are not cached,
+// neither the decisions of the arguments which are not scalar, such as the pointer, struct
+// and map of the ABAC model, see NewRequest.
+// NOTE: it must be cleared when the policy changes, see NewCacheWatcher,
+// which is not called by e.LoadPolicy or if e.EnableAutoNotifyWatcher(false), call Clear after them.
+type DecisionCache struct {
+	cache *cache.Cache
+	size  int
+	// mu guards generation and the store of the decisions, Clear bumps the generation,
+	// so the decision evaluated before it is dropped.
+	mu         sync.Mutex
+	generation uint64
+}
+
+// NewDecisionCache new DecisionCache, the decision expires after ttl.
+// size the max number of the decisions, the new decisions are not cached when it is full
+// until the expired ones are deleted, if size <= 0, use DefaultDecisionCacheSize.
+func NewDecisionCache(ttl time.Duration, size int) *DecisionCache {
+	if size <= 0 {
+		size = DefaultDecisionCacheSize
+	}
+	return &DecisionCache{cache: cache.New(ttl, 2*ttl), size: size}
+}
+
+// Clear clears all the decisions.
+func (d *DecisionCache) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.generation++
+	d.cache.Flush()
+}
+
+// decision the cached decision, explained reports whether explain is evaluated by EnforceEx.
+type decision struct {
+	allowed   bool
+	explain   []string
+	explained bool
+}
+
+// Enforce returns the cached decision, otherwise enforces and caches it.
+func (d *DecisionCache) Enforce(e casbin.IEnforcer, rvals ...any) (bool, error) {
+	allowed, _, err := d.enforce(e, rvals, false)
+	return allowed, err
+}
+
+// enforce returns the cached decision, otherwise enforces and caches it,
+// the explanation is evaluated by EnforceEx if explain is true, the cached one without it is evaluated again.
+func (d *DecisionCache) enforce(e casbin.IEnforcer, rvals []any, explain bool) (bool, []string, error) {
+	key, ok := decisionKey(rvals)
+	if !ok {
+		return enforce(e, rvals, explain)
+	}
+	if v, ok := d.cache.Get(key); ok {
+		if dec := v.(decision); dec.explained || !explain {
+			return dec.allowed, dec.explain, nil
+		}
+	}
+	generation := d.loadGeneration()
+	allowed, explains, err := enforce(e, rvals, explain)
+	if err != nil {
+		return false, nil, err
+	}
+	d.store(generation, map[string]decision{key: {allowed, explains, explain}})
+	return allowed, explains, nil
+}
+
+// enforce enforces the decision, and the matched policy rule by EnforceEx if explain is true.
+func enforce(e casbin.IEnforcer, rvals []any, explain bool) (bool, []string, error) {
+	if explain {
+		return e.EnforceEx(rvals...)
+	}
+	allowed, err := e.Enforce(rvals...)
+	return allowed, nil, err
+}
+
+func (d *DecisionCache) loadGeneration() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.generation
+}
+
+// store stores the decisions evaluated in the generation, they are dropped if the cache is cleared since then.
+func (d *DecisionCache) store(generation uint64, decisions map[string]decision) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if generation != d.generation {
+		return
+	}
+	for key, dec := range decisions {
+		if d.cache.ItemCount() >= d.size {
+			return
+		}
+		d.cache.SetDefault(key, dec)
+	}
+}
+
+// decisionKey the key of the enforcement arguments, it reports false if any argument is not scalar,
+// the pointer address can be reused and the content of the struct or map can be changed.
+func decisionKey(rvals []any) (string, bool) {
+	for _, v := range rvals {
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%#v", rvals), true
+}
+
+// BatchEnforce evaluates the requests in one batch call, such as the permissions of the buttons of the UI.
+// the cached decisions are used, and only the others are enforced if cache is not nil.
+func BatchEnforce(e casbin.IEnforcer, d *DecisionCache, requests ...[]any) ([]bool, error) {
+	if d == nil {
+		return e.BatchEnforce(requests)
+	}
+	results := make([]bool, len(requests))
+	missIndex := make([]int, 0, len(requests))
+	missKeys := make([]string, 0, len(requests))
+	missRequests := make([][]any, 0, len(requests))
+	for i, rvals := range requests {
+		key, ok := decisionKey(rvals)
+		if ok {
+			if v, found := d.cache.Get(key); found {
+				results[i] = v.(decision).allowed
+				continue
+			}
+		}
+		missIndex = append(missIndex, i)
+		missKeys = append(missKeys, key)
+		missRequests = append(missRequests, rvals)
+	}
+	if len(missRequests) == 0 {
+		return results, nil
+	}
+	generation := d.loadGeneration()
+	decisions, err := e.BatchEnforce(missRequests)
+	if err != nil {
+		return nil, err
+	}
+	cached := make(map[string]decision, len(decisions))
+	for i, allowed := range decisions {
+		results[missIndex[i]] = allowed
+		if missKeys[i] != "" {
+			cached[missKeys[i]] = decision{allowed: allowed}
+		}
+	}
+	d.store(generation, cached)
+	return results, nil
+}
+
+// cacheWatcher clears the DecisionCache when the policy changes.
+type cacheWatcher struct {
+	watcher persist.Watcher
+	cache   *DecisionCache
+}
+
+// NewCacheWatcher returns a persist.Watcher which clears the DecisionCache when the policy changes,
+// both by this instance and by the other instances notified through the watcher, set it with e.SetWatcher.
+// watcher is the distributed watcher, such as the redis watcher, it can be nil if there is only one instance.
+// NOTE: the persist.WatcherEx of the watcher is not used, the whole policy is reloaded on change.
+func NewCacheWatcher(watcher persist.Watcher, d *DecisionCache) persist.Watcher {
+	return &cacheWatcher{watcher: watcher, cache: d}
+}
+
+// SetUpdateCallback implement persist.Watcher interface, the cache is cleared after the callback,
+// which reloads the policy.
+func (w *cacheWatcher) SetUpdateCallback(fn func(string)) error {
+	if w.watcher == nil {
+		return nil
+	}
+	return w.watcher.SetUpdateCallback(func(msg string) {
+		fn(msg)
+		w.cache.Clear()
+	})
+}
+
+// Update implement persist.Watcher interface, it is called when the policy is changed by this instance.
+func (w *cacheWatcher) Update() error {
+	w.cache.Clear()
+	if w.watcher == nil {
+		return nil
+	}
+	return w.watcher.Update()
+}
+
+// Close implement persist.Watcher interface.
+func (w *cacheWatcher) Close() {
+	if w.watcher != nil {
+		w.watcher.Close()
+	}
+}
@@ -0,0 +1,176 @@
+package authj
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// testWatcher a distributed watcher stand-in, notify simulates the update from the other instances.
+type testWatcher struct {
+	callback func(string)
+	updated  int
+}
+
+func (w *testWatcher) SetUpdateCallback(fn func(string)) error { w.callback = fn; return nil }
+func (w *testWatcher) Update() error                           { w.updated++; return nil }
+func (w *testWatcher) Close()                                  {}
+func (w *testWatcher) notify()                                 { w.callback("") }
+
+func TestDecisionCache(t *testing.T) {
+	// the policy is saved by SavePolicy, so copy it.
+	policy, err := os.ReadFile("authj_policy.csv")
+	require.NoError(t, err)
+	policyFile := filepath.Join(t.TempDir(), "authj_policy.csv")
+	require.NoError(t, os.WriteFile(policyFile, policy, 0o600))
+	e, err := casbin.NewEnforcer("authj_model.conf", policyFile)
+	require.NoError(t, err)
+	d := NewDecisionCache(time.Minute, 0)
+
+	allowed, err := d.Enforce(e, "alice", "/dataset1/resource1", http.MethodPost)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// the policy changes without the watcher, the decision is stale.
+	_, err = e.RemovePolicy("alice", "/dataset1/resource1", "POST")
+	require.NoError(t, err)
+	allowed, err = d.Enforce(e, "alice", "/dataset1/resource1", http.MethodPost)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// the policy changes by this instance.
+	watcher := &testWatcher{}
+	require.NoError(t, e.SetWatcher(NewCacheWatcher(watcher, d)))
+	_, err = e.RemovePolicy("alice", "/dataset1/*", "GET")
+	require.NoError(t, err)
+	require.Equal(t, 1, watcher.updated)
+	allowed, err = d.Enforce(e, "alice", "/dataset1/resource1", http.MethodPost)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	// the policy changes by the other instances.
+	allowed, err = d.Enforce(e, "bob", "/dataset2/resource2", http.MethodGet)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	e.EnableAutoSave(false)
+	e.EnableAutoNotifyWatcher(false)
+	_, err = e.RemovePolicy("bob", "/dataset2/resource2", "GET")
+	require.NoError(t, err)
+	require.NoError(t, e.SavePolicy())
+	watcher.notify()
+	allowed, err = d.Enforce(e, "bob", "/dataset2/resource2", http.MethodGet)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func TestWithDecisionCache(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	d := NewDecisionCache(time.Minute, 0)
+	require.NoError(t, e.SetWatcher(NewCacheWatcher(nil, d)))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, "cathy")
+	})
+	router.Use(Authorizer(e, WithDecisionCache(d)))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testAuthjRequest(t, router, "cathy", "/dataset1/item", http.MethodGet, http.StatusOK)
+	testAuthjRequest(t, router, "cathy", "/dataset1/item", http.MethodGet, http.StatusOK)
+	_, err = e.DeleteRolesForUser("cathy")
+	require.NoError(t, err)
+	testAuthjRequest(t, router, "cathy", "/dataset1/item", http.MethodGet, http.StatusForbidden)
+}
+
+func TestBatchEnforce(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	requests := [][]any{
+		{"alice", "/dataset1/resource1", http.MethodGet},
+		{"alice", "/dataset1/resource1", http.MethodDelete},
+		{"alice", "/dataset2/resource1", http.MethodGet},
+		{"cathy", "/dataset1/resource1", http.MethodDelete},
+	}
+	want := []bool{true, false, false, true}
+
+	results, err := BatchEnforce(e, nil, requests...)
+	require.NoError(t, err)
+	require.Equal(t, want, results)
+
+	d := NewDecisionCache(time.Minute, 0)
+	_, err = d.Enforce(e, requests[1]...)
+	require.NoError(t, err)
+	results, err = BatchEnforce(e, d, requests...)
+	require.NoError(t, err)
+	require.Equal(t, want, results)
+	// all are cached now.
+	_, err = e.DeleteRolesForUser("cathy")
+	require.NoError(t, err)
+	results, err = BatchEnforce(e, d, requests...)
+	require.NoError(t, err)
+	require.Equal(t, want, results)
+}
+
+// clearingEnforcer clears the cache while the decision is evaluated, such as the policy changes concurrently.
+type clearingEnforcer struct {
+	casbin.IEnforcer
+	d *DecisionCache
+}
+
+func (e clearingEnforcer) Enforce(rvals ...any) (bool, error) {
+	defer e.d.Clear()
+	return e.IEnforcer.Enforce(rvals...)
+}
+
+func (e clearingEnforcer) BatchEnforce(requests [][]any) ([]bool, error) {
+	defer e.d.Clear()
+	return e.IEnforcer.BatchEnforce(requests)
+}
+
+func TestDecisionCache_Store(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+
+	t.Run("cleared while evaluating", func(t *testing.T) {
+		d := NewDecisionCache(time.Minute, 0)
+		allowed, err := d.Enforce(clearingEnforcer{e, d}, "alice", "/dataset1/resource1", http.MethodGet)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		_, err = BatchEnforce(clearingEnforcer{e, d}, d, []any{"alice", "/dataset1/resource1", http.MethodPost})
+		require.NoError(t, err)
+		require.Zero(t, d.cache.ItemCount())
+	})
+	t.Run("not scalar", func(t *testing.T) {
+		_, ok := decisionKey([]any{"alice", 1, 2.5, true, uint8(1)})
+		require.True(t, ok)
+		for _, v := range []any{nil, &Route{}, Route{}, map[string]string{}, []string{}} {
+			_, ok = decisionKey([]any{"alice", v})
+			require.False(t, ok)
+		}
+	})
+	t.Run("size", func(t *testing.T) {
+		d := NewDecisionCache(time.Minute, 2)
+		requests := [][]any{
+			{"alice", "/dataset1/resource1", http.MethodGet},
+			{"alice", "/dataset1/resource1", http.MethodPost},
+			{"alice", "/dataset2/resource1", http.MethodGet},
+		}
+		results, err := BatchEnforce(e, d, requests...)
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, true, false}, results)
+		require.Equal(t, 2, d.cache.ItemCount())
+		allowed, err := d.Enforce(e, "bob", "/dataset2/resource2", http.MethodGet)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		require.Equal(t, 2, d.cache.ItemCount())
+	})
+}
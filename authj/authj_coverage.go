@@ -0,0 +1,199 @@
+package authj
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrPolicyDrift indicates the policies do not match the registered routes.
+var ErrPolicyDrift = errors.New("authj: policies drift from routes")
+
+// CoverageRequest builds the enforcement arguments which check whether the policy rule matches the route,
+// such as (rule[0], route.Path, route.Method) or (rule[0], rule[1], route.Path, route.Method) of the domains model.
+type CoverageRequest func(rule []string, route gin.RouteInfo) []any
+
+// DefaultCoverageRequest the default CoverageRequest, which is (rule subject, route path, route method),
+// the route path is the route template, such as "/users/:id", as the object of RouteRequest.
+func DefaultCoverageRequest(rule []string, route gin.RouteInfo) []any {
+	return []any{rule[0], route.Path, route.Method}
+}
+
+type coverageConfig struct {
+	request   CoverageRequest
+	ignore    func(gin.RouteInfo) bool
+	functions map[string]func(args ...any) (any, error)
+}
+
+// CoverageOption coverage option
+type CoverageOption func(*coverageConfig)
+
+// WithCoverageRequest set the enforcement arguments builder of the coverage check.
+// default: DefaultCoverageRequest
+func WithCoverageRequest(fn CoverageRequest) CoverageOption {
+	return func(cfg *coverageConfig) {
+		if fn != nil {
+			cfg.request = fn
+		}
+	}
+}
+
+// WithIgnoreRoutes set the routes which are not checked when it is return true, such as the health check.
+// default: always false
+func WithIgnoreRoutes(fn func(gin.RouteInfo) bool) CoverageOption {
+	return func(cfg *coverageConfig) {
+		if fn != nil {
+			cfg.ignore = fn
+		}
+	}
+}
+
+// WithCoverageFunction adds the custom function of the matcher to the enforcer of the coverage check,
+// the function added to the enforcer by AddFunction must be added again, it can not be copied from the enforcer.
+func WithCoverageFunction(name string, fn func(args ...any) (any, error)) CoverageOption {
+	return func(cfg *coverageConfig) {
+		if fn != nil {
+			if cfg.functions == nil {
+				cfg.functions = make(map[string]func(args ...any) (any, error))
+			}
+			cfg.functions[name] = fn
+		}
+	}
+}
+
+// Coverage the report of the policy coverage of the routes.
+type Coverage struct {
+	// Uncovered the routes which no policy rule allows.
+	Uncovered []gin.RouteInfo
+	// Unused the policy rules which match no route.
+	Unused [][]string
+}
+
+// OK reports whether all routes are covered and all policy rules are used.
+func (c *Coverage) OK() bool { return len(c.Uncovered) == 0 && len(c.Unused) == 0 }
+
+// Err returns ErrPolicyDrift with the details if the coverage is not OK, otherwise nil.
+func (c *Coverage) Err() error {
+	if c.OK() {
+		return nil
+	}
+	b := strings.Builder{}
+	for _, route := range c.Uncovered {
+		fmt.Fprintf(&b, "\n\tuncovered route: %s %s", route.Method, route.Path)
+	}
+	for _, rule := range c.Unused {
+		fmt.Fprintf(&b, "\n\tunused policy: %s", strings.Join(rule, ", "))
+	}
+	return fmt.Errorf("%w:%s", ErrPolicyDrift, b.String())
+}
+
+// CheckCoverage checks each policy rule of the enforcer against the routes, such as gin.Engine.Routes(),
+// the rule is evaluated alone with the model of the enforcer, so the routes are checked as the policies
+// are written.
+// the role managers of the enforcer are shared, so the role links and the matching functions are kept,
+// but the custom functions of the matcher are not, add them by WithCoverageFunction.
+// NOTE: the object of DefaultCoverageRequest is the route template, so the policies must be written
+// against the route templates, as RouteRequest does. if the policies are written against the request
+// paths, as DefaultRequest does, supply the object by WithCoverageRequest, such as a sample path of the route.
+func CheckCoverage(e casbin.IEnforcer, routes gin.RoutesInfo, opts ...CoverageOption) (*Coverage, error) {
+	cfg := coverageConfig{
+		request: DefaultCoverageRequest,
+		ignore:  func(gin.RouteInfo) bool { return false },
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	rules, err := e.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	// evaluate the rule alone, so the matched rule is known.
+	scratch, err := casbin.NewEnforcer(e.GetModel().Copy())
+	if err != nil {
+		return nil, err
+	}
+	for name, fn := range cfg.functions {
+		scratch.AddFunction(name, fn)
+	}
+	// share the role managers, which are only read by the rule evaluation.
+	scratchRoles := scratch.GetModel()["g"]
+	for ptype, ast := range e.GetModel()["g"] {
+		scratchRoles[ptype].RM, scratchRoles[ptype].CondRM = ast.RM, ast.CondRM
+	}
+	covered := make([]bool, len(routes))
+	coverage := &Coverage{}
+	for _, rule := range rules {
+		scratch.ClearPolicy()
+		if _, err = scratch.AddPolicy(rule); err != nil {
+			return nil, err
+		}
+		used := false
+		for i, route := range routes {
+			if cfg.ignore(route) {
+				continue
+			}
+			allowed, explain, err := scratch.EnforceEx(cfg.request(rule, route)...)
+			if err != nil {
+				return nil, err
+			}
+			covered[i] = covered[i] || allowed
+			used = used || allowed || len(explain) > 0
+		}
+		if !used {
+			coverage.Unused = append(coverage.Unused, rule)
+		}
+	}
+	for i, route := range routes {
+		if !covered[i] && !cfg.ignore(route) {
+			coverage.Uncovered = append(coverage.Uncovered, route)
+		}
+	}
+	return coverage, nil
+}
+
+// VerifyCoverage checks the coverage at startup, returns ErrPolicyDrift if it is not OK.
+func VerifyCoverage(e casbin.IEnforcer, routes gin.RoutesInfo, opts ...CoverageOption) error {
+	coverage, err := CheckCoverage(e, routes, opts...)
+	if err != nil {
+		return err
+	}
+	return coverage.Err()
+}
+
+// AdminAllow returns the policy rule (sub, path, method) of the route, which allows the subject, such as the admin role.
+func AdminAllow(sub string) func(gin.RouteInfo) []string {
+	return func(route gin.RouteInfo) []string {
+		return []string{sub, route.Path, route.Method}
+	}
+}
+
+// DefaultDeny returns the policy rule (sub, path, method, "deny") of the route, which denies the subject,
+// the model must have the `eft` field of the policy and a deny effect.
+func DefaultDeny(sub string) func(gin.RouteInfo) []string {
+	return func(route gin.RouteInfo) []string {
+		return []string{sub, route.Path, route.Method, "deny"}
+	}
+}
+
+// RoutePolicies returns the policy rules of the routes generated by the rule, such as AdminAllow and DefaultDeny.
+func RoutePolicies(routes gin.RoutesInfo, rule func(gin.RouteInfo) []string) [][]string {
+	rules := make([][]string, 0, len(routes))
+	for _, route := range routes {
+		rules = append(rules, rule(route))
+	}
+	return rules
+}
+
+// SeedPolicies adds the policy rules which are not present through the enforcer,
+// they are persisted by the auto save of the adapter, call e.SavePolicy for the adapter
+// which does not support auto save, such as the file adapter.
+func SeedPolicies(e casbin.IEnforcer, rules [][]string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	_, err := e.AddPoliciesEx(rules)
+	return err
+}
@@ -0,0 +1,138 @@
+package authj
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCoverageRouter() *gin.Engine {
+	router := gin.New()
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/dataset1/:id", handler)
+	router.POST("/dataset1/resource1", handler)
+	router.GET("/dataset2/resource2", handler)
+	router.DELETE("/dataset3/:id", handler)
+	router.GET("/healthz", handler)
+	return router
+}
+
+func TestCheckCoverage(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	router := newTestCoverageRouter()
+
+	coverage, err := CheckCoverage(e, router.Routes(), WithIgnoreRoutes(func(route gin.RouteInfo) bool {
+		return route.Path == "/healthz"
+	}))
+	require.NoError(t, err)
+	require.False(t, coverage.OK())
+	require.Len(t, coverage.Uncovered, 1)
+	require.Equal(t, "/dataset3/:id", coverage.Uncovered[0].Path)
+	require.ElementsMatch(t, [][]string{
+		{"bob", "/dataset2/resource1", "*"},
+		{"bob", "/dataset2/folder1/*", "POST"},
+	}, coverage.Unused)
+	require.ErrorIs(t, coverage.Err(), ErrPolicyDrift)
+
+	err = VerifyCoverage(e, router.Routes())
+	require.ErrorIs(t, err, ErrPolicyDrift)
+	require.Contains(t, err.Error(), "uncovered route: GET /healthz")
+	require.Contains(t, err.Error(), "unused policy: bob, /dataset2/resource1, *")
+}
+
+func TestCheckCoverage_SamplePath(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	routes := gin.RoutesInfo{{Method: http.MethodGet, Path: "/dataset2/:id"}}
+
+	// the policies are written against the request paths, not the route template.
+	coverage, err := CheckCoverage(e, routes)
+	require.NoError(t, err)
+	require.Len(t, coverage.Uncovered, 1)
+
+	samples := map[string]string{"/dataset2/:id": "/dataset2/resource2"}
+	coverage, err = CheckCoverage(e, routes, WithCoverageRequest(func(rule []string, route gin.RouteInfo) []any {
+		return []any{rule[0], samples[route.Path], route.Method}
+	}))
+	require.NoError(t, err)
+	require.Empty(t, coverage.Uncovered)
+}
+
+func TestCheckCoverage_CustomFunction(t *testing.T) {
+	m, err := model.NewModelFromString(`
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && routeMatch(r.obj, p.obj) && r.act == p.act
+`)
+	require.NoError(t, err)
+	routeMatch := func(args ...any) (any, error) {
+		return util.KeyMatch2(args[0].(string), args[1].(string)), nil
+	}
+	e, err := casbin.NewEnforcer(m)
+	require.NoError(t, err)
+	e.AddFunction("routeMatch", routeMatch)
+	_, err = e.AddPolicy("admin", "/users/:id", http.MethodGet)
+	require.NoError(t, err)
+	_, err = e.AddGroupingPolicy("alice", "admin")
+	require.NoError(t, err)
+	routes := gin.RoutesInfo{{Method: http.MethodGet, Path: "/users/:id"}}
+
+	// the custom function can not be copied from the enforcer.
+	_, err = CheckCoverage(e, routes)
+	require.Error(t, err)
+
+	// the role links of the enforcer are kept.
+	coverage, err := CheckCoverage(e, routes,
+		WithCoverageFunction("routeMatch", routeMatch),
+		WithCoverageRequest(func(_ []string, route gin.RouteInfo) []any {
+			return []any{"alice", route.Path, route.Method}
+		}),
+	)
+	require.NoError(t, err)
+	require.True(t, coverage.OK())
+	allowed, err := e.Enforce("alice", "/users/1", http.MethodGet)
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
+
+func TestSeedPolicies(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf")
+	require.NoError(t, err)
+	router := newTestCoverageRouter()
+
+	err = SeedPolicies(e, RoutePolicies(router.Routes(), AdminAllow("admin")))
+	require.NoError(t, err)
+	// seeding again keeps the existing ones.
+	err = SeedPolicies(e, RoutePolicies(router.Routes(), AdminAllow("admin")))
+	require.NoError(t, err)
+	rules, err := e.GetPolicy()
+	require.NoError(t, err)
+	require.Len(t, rules, len(router.Routes()))
+	require.NoError(t, VerifyCoverage(e, router.Routes()))
+
+	allowed, err := e.Enforce("admin", "/dataset3/:id", http.MethodDelete)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	require.Equal(t, []string{"guest", "/healthz", http.MethodGet, "deny"}, DefaultDeny("guest")(gin.RouteInfo{
+		Method: http.MethodGet,
+		Path:   "/healthz",
+	}))
+}
@@ -0,0 +1,91 @@
+package authj
+
+import (
+	"net"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestBuilder builds the casbin enforcement arguments of the request with the subject,
+// the arguments must match the request_definition of the model, such as (sub, obj, act)
+// or (sub, dom, obj, act), the error is handled by the error fallback.
+type RequestBuilder func(c *gin.Context, subject string) ([]any, error)
+
+// Arg returns an enforcement argument of the request.
+type Arg func(c *gin.Context, subject string) any
+
+// the built-in RequestBuilder.
+var (
+	// DefaultRequest the default RequestBuilder, which is (subject, path, method).
+	DefaultRequest = NewRequest(ArgSubject, ArgPath, ArgMethod)
+	// RouteRequest the RequestBuilder which is (subject, route template, method),
+	// so the policies are written against the registered routes, such as "/users/:id/orders".
+	RouteRequest = NewRequest(ArgSubject, ArgFullPath, ArgMethod)
+)
+
+// NewRequest returns a RequestBuilder with the args in order,
+// such as NewRequest(ArgSubject, ArgHeader("X-Tenant-Id"), ArgPath, ArgMethod) for the RBAC with domains model.
+func NewRequest(args ...Arg) RequestBuilder {
+	return func(c *gin.Context, subject string) ([]any, error) {
+		rvals := make([]any, 0, len(args))
+		for _, arg := range args {
+			rvals = append(rvals, arg(c, subject))
+		}
+		return rvals, nil
+	}
+}
+
+// ArgSubject the subject of the request, see WithSubject.
+func ArgSubject(_ *gin.Context, subject string) any { return subject }
+
+// ArgPath the raw path of the request.
+func ArgPath(c *gin.Context, _ string) any { return c.Request.URL.Path }
+
+// ArgFullPath the route template of the request, such as "/users/:id/orders",
+// empty if no route matched, so the request is denied.
+func ArgFullPath(c *gin.Context, _ string) any { return c.FullPath() }
+
+// Route the matched route with the path parameters, which are exposed to the matchers,
+// such as `keyMatch(r.obj.Path, p.obj) && r.obj.Params.id == r.sub`.
+type Route struct {
+	// Path the route template, such as "/users/:id/orders".
+	Path string
+	// Params the path parameters by name.
+	Params map[string]string
+}
+
+// ArgRoute the Route of the request.
+func ArgRoute(c *gin.Context, _ string) any {
+	params := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return Route{Path: c.FullPath(), Params: params}
+}
+
+// ArgMethod the method of the request.
+func ArgMethod(c *gin.Context, _ string) any { return c.Request.Method }
+
+// ArgHost the host of the request without port, such as the tenant domain.
+func ArgHost(c *gin.Context, _ string) any {
+	host, _, err := net.SplitHostPort(c.Request.Host)
+	if err != nil {
+		return c.Request.Host
+	}
+	return host
+}
+
+// ArgHeader returns an Arg of the header value of the request, empty if not present.
+func ArgHeader(name string) Arg {
+	return func(c *gin.Context, _ string) any { return c.GetHeader(name) }
+}
+
+// ArgParam returns an Arg of the path parameter of the route, empty if not present.
+func ArgParam(name string) Arg {
+	return func(c *gin.Context, _ string) any { return c.Param(name) }
+}
+
+// ArgValue returns an Arg of the value, such as the fixed domain.
+func ArgValue(v any) Arg {
+	return func(*gin.Context, string) any { return v }
+}
@@ -0,0 +1,162 @@
+package authj
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+const testDomainModel = `
+[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub, r.dom) && r.dom == p.dom && keyMatch(r.obj, p.obj) && r.act == p.act
+`
+
+func newTestDomainEnforcer(t *testing.T) *casbin.Enforcer {
+	m, err := model.NewModelFromString(testDomainModel)
+	require.NoError(t, err)
+	e, err := casbin.NewEnforcer(m)
+	require.NoError(t, err)
+	_, err = e.AddPolicy("admin", "tenant1", "/data/*", "GET")
+	require.NoError(t, err)
+	_, err = e.AddGroupingPolicy("alice", "admin", "tenant1")
+	require.NoError(t, err)
+	_, err = e.AddGroupingPolicy("bob", "admin", "tenant2")
+	require.NoError(t, err)
+	return e
+}
+
+func TestWithRequest_Domain(t *testing.T) {
+	e := newTestDomainEnforcer(t)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, c.GetHeader("X-User"))
+	})
+	router.Use(Authorizer(e, WithRequest(NewRequest(ArgSubject, ArgHeader("X-Tenant-Id"), ArgPath, ArgMethod))))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, tt := range []struct {
+		user, tenant, method string
+		code                 int
+	}{
+		{"alice", "tenant1", http.MethodGet, http.StatusOK},
+		{"alice", "tenant1", http.MethodPost, http.StatusForbidden},
+		{"alice", "tenant2", http.MethodGet, http.StatusForbidden},
+		{"alice", "", http.MethodGet, http.StatusForbidden},
+		{"bob", "tenant1", http.MethodGet, http.StatusForbidden},
+		{"bob", "tenant2", http.MethodGet, http.StatusForbidden},
+	} {
+		r, _ := http.NewRequestWithContext(context.TODO(), tt.method, "/data/1", http.NoBody)
+		r.Header.Set("X-User", tt.user)
+		r.Header.Set("X-Tenant-Id", tt.tenant)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		require.Equal(t, tt.code, w.Code, "%s %s %s", tt.user, tt.tenant, tt.method)
+	}
+}
+
+func TestWithRequest_Error(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(Authorizer(e, WithRequest(func(*gin.Context, string) ([]any, error) {
+		return nil, errors.New("missing tenant")
+	})))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodGet, http.StatusInternalServerError)
+}
+
+func TestNewRequest(t *testing.T) {
+	router := gin.New()
+	router.GET("/users/:id", func(c *gin.Context) {
+		rvals, err := NewRequest(ArgSubject, ArgHost, ArgParam("id"), ArgValue("fixed"), ArgMethod)(c, "alice")
+		require.NoError(t, err)
+		require.Equal(t, []any{"alice", "example.com", "42", "fixed", http.MethodGet}, rvals)
+	})
+	r, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://example.com:8080/users/42", http.NoBody)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestRouteRequest(t *testing.T) {
+	e, err := casbin.NewEnforcer("authj_model.conf")
+	require.NoError(t, err)
+	_, err = e.AddPolicy("alice", "/users/:id/orders", "GET")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.UseRawPath = true
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, "alice")
+	})
+	router.Use(Authorizer(e, WithRequest(RouteRequest)))
+	router.GET("/users/:id/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/users/:id/files/*file", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testAuthjRequest(t, router, "alice", "/users/123/orders", http.MethodGet, http.StatusOK)
+	testAuthjRequest(t, router, "alice", "/users/a%2Fb/orders", http.MethodGet, http.StatusOK)
+	testAuthjRequest(t, router, "alice", "/users/123/files/1", http.MethodGet, http.StatusForbidden)
+	testAuthjRequest(t, router, "alice", "/users/123/orders", http.MethodPost, http.StatusForbidden)
+	// no route matched, the route template is empty.
+	testAuthjRequest(t, router, "alice", "/unknown", http.MethodGet, http.StatusForbidden)
+}
+
+func TestArgRoute(t *testing.T) {
+	m, err := model.NewModelFromString(`
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.obj.Path == p.obj && r.act == p.act && (p.sub == "*" || r.obj.Params.id == r.sub)
+`)
+	require.NoError(t, err)
+	e, err := casbin.NewEnforcer(m)
+	require.NoError(t, err)
+	_, err = e.AddPolicy("owner", "/users/:id/orders", "GET")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, "123")
+	})
+	router.Use(Authorizer(e, WithRequest(NewRequest(ArgSubject, ArgRoute, ArgMethod))))
+	router.GET("/users/:id/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testAuthjRequest(t, router, "123", "/users/123/orders", http.MethodGet, http.StatusOK)
+	testAuthjRequest(t, router, "123", "/users/456/orders", http.MethodGet, http.StatusForbidden)
+}
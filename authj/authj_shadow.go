@@ -0,0 +1,113 @@
+package authj
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNoCandidate indicates the shadow has no candidate enforcer.
+var ErrNoCandidate = errors.New("authj: no candidate enforcer")
+
+// ShadowDiff the difference between the decisions of the live and the shadow enforcer.
+type ShadowDiff struct {
+	// Subject the subject of the request.
+	Subject string
+	// Args the enforcement arguments.
+	Args []any
+	// Allowed the decision of the live enforcer, which is enforced.
+	Allowed bool
+	// ShadowAllowed the decision of the shadow enforcer, which is not enforced.
+	ShadowAllowed bool
+	// ShadowErr the error of the shadow enforcer.
+	ShadowErr error
+	// Promoted whether the candidate is live, the shadow is the enforcer of Authorizer.
+	Promoted bool
+	// TraceId the trace id of the request, see traceid.TraceId.
+	TraceId string
+}
+
+// ShadowReporter reports the differences of the shadow, such as logging them.
+type ShadowReporter interface {
+	Report(c *gin.Context, d *ShadowDiff)
+}
+
+// ShadowReporterFunc is an adapter to allow the use of ordinary functions as ShadowReporter.
+type ShadowReporterFunc func(c *gin.Context, d *ShadowDiff)
+
+// Report calls f(c, d).
+func (f ShadowReporterFunc) Report(c *gin.Context, d *ShadowDiff) { f(c, d) }
+
+type shadowState struct {
+	candidate casbin.IEnforcer
+	promoted  bool
+}
+
+// Shadow evaluates the candidate enforcer in dry-run next to the enforcer of Authorizer,
+// and reports the differences without enforcing them, the candidate can be promoted to live
+// at runtime, then the enforcer of Authorizer is evaluated in dry-run, so it can be rolled back.
+// it is safe for concurrent use, use it with WithShadow.
+type Shadow struct {
+	reporter ShadowReporter
+	state    atomic.Pointer[shadowState]
+}
+
+// NewShadow new Shadow with the reporter, there is no candidate until SetCandidate.
+// the differences are dropped if reporter is nil, such as only the rollout is needed.
+func NewShadow(reporter ShadowReporter) *Shadow {
+	if reporter == nil {
+		reporter = ShadowReporterFunc(func(*gin.Context, *ShadowDiff) {})
+	}
+	return &Shadow{reporter: reporter}
+}
+
+// SetCandidate sets the candidate enforcer in dry-run, nil stops the dry-run.
+func (s *Shadow) SetCandidate(e casbin.IEnforcer) {
+	if e == nil {
+		s.state.Store(nil)
+	} else {
+		s.state.Store(&shadowState{candidate: e})
+	}
+}
+
+// Candidate returns the candidate enforcer, and whether it is promoted.
+func (s *Shadow) Candidate() (e casbin.IEnforcer, promoted bool) {
+	if st := s.state.Load(); st != nil {
+		return st.candidate, st.promoted
+	}
+	return nil, false
+}
+
+// Promote enforces the decisions of the candidate, returns ErrNoCandidate if no candidate.
+func (s *Shadow) Promote() error { return s.setPromoted(true) }
+
+// Rollback enforces the decisions of the enforcer of Authorizer again, the candidate is back in dry-run,
+// returns ErrNoCandidate if no candidate.
+func (s *Shadow) Rollback() error { return s.setPromoted(false) }
+
+func (s *Shadow) setPromoted(promoted bool) error {
+	for {
+		st := s.state.Load()
+		if st == nil {
+			return ErrNoCandidate
+		}
+		if s.state.CompareAndSwap(st, &shadowState{candidate: st.candidate, promoted: promoted}) {
+			return nil
+		}
+	}
+}
+
+// enforcers returns the live and the shadow enforcer, the shadow is nil if there is no candidate.
+func (s *Shadow) enforcers(e casbin.IEnforcer) (live, shadow casbin.IEnforcer, promoted bool) {
+	st := s.state.Load()
+	switch {
+	case st == nil:
+		return e, nil, false
+	case st.promoted:
+		return st.candidate, e, true
+	default:
+		return e, st.candidate, false
+	}
+}
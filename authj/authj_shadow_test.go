@@ -0,0 +1,74 @@
+package authj
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithShadow(t *testing.T) {
+	live, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	// the stricter candidate, alice can not post anymore.
+	candidate, err := casbin.NewEnforcer("authj_model.conf", "authj_policy.csv")
+	require.NoError(t, err)
+	_, err = candidate.RemovePolicy("alice", "/dataset1/resource1", "POST")
+	require.NoError(t, err)
+
+	var diffs []*ShadowDiff
+	shadow := NewShadow(ShadowReporterFunc(func(c *gin.Context, d *ShadowDiff) {
+		diffs = append(diffs, d)
+	}))
+	require.ErrorIs(t, shadow.Promote(), ErrNoCandidate)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ContextWithSubject(c, "alice")
+	})
+	router.Use(Authorizer(live, WithShadow(shadow), WithDecisionCache(NewDecisionCache(time.Minute, 0))))
+	router.Any("/*anypath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// no candidate.
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodPost, http.StatusOK)
+	require.Empty(t, diffs)
+
+	// dry-run, the difference is reported but not enforced.
+	shadow.SetCandidate(candidate)
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodGet, http.StatusOK)
+	require.Empty(t, diffs)
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodPost, http.StatusOK)
+	require.Len(t, diffs, 1)
+	require.Equal(t, &ShadowDiff{
+		Subject:       "alice",
+		Args:          []any{"alice", "/dataset1/resource1", http.MethodPost},
+		Allowed:       true,
+		ShadowAllowed: false,
+	}, diffs[0])
+
+	// promoted, the candidate is enforced, and the previous one is in dry-run.
+	require.NoError(t, shadow.Promote())
+	e, promoted := shadow.Candidate()
+	require.Equal(t, candidate, e)
+	require.True(t, promoted)
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodPost, http.StatusForbidden)
+	require.Len(t, diffs, 2)
+	require.False(t, diffs[1].Allowed)
+	require.True(t, diffs[1].ShadowAllowed)
+	require.True(t, diffs[1].Promoted)
+
+	// rollback.
+	require.NoError(t, shadow.Rollback())
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodPost, http.StatusOK)
+	require.Len(t, diffs, 3)
+
+	// stop the dry-run.
+	shadow.SetCandidate(nil)
+	testAuthjRequest(t, router, "alice", "/dataset1/resource1", http.MethodPost, http.StatusOK)
+	require.Len(t, diffs, 3)
+}
@@ -0,0 +1,136 @@
+// Package authjzap provides the authj.Auditor using zap package,
+// the fields are named as gzap, and the custom fields of gzap can be used.
+package authjzap
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/things-go/gin-contrib/authj"
+	"github.com/things-go/gin-contrib/internal/pool"
+)
+
+// Option auditor option
+type Option func(c *Config)
+
+// WithMessage optional custom message of the log.
+// default: "authorization"
+func WithMessage(msg string) Option {
+	return func(c *Config) {
+		if msg != "" {
+			c.message = msg
+		}
+	}
+}
+
+// WithCustomFields optional custom field, such as gzap.String.
+func WithCustomFields(fields ...func(c *gin.Context) zap.Field) Option {
+	return func(c *Config) {
+		c.customFields = fields
+	}
+}
+
+// WithUseLoggerLevel optional use logging level.
+func WithUseLoggerLevel(f func(r *authj.AuditRecord) zapcore.Level) Option {
+	return func(c *Config) {
+		if f != nil {
+			c.useLoggerLevel = f
+		}
+	}
+}
+
+// Config auditor config
+type Config struct {
+	message      string
+	customFields []func(c *gin.Context) zap.Field
+	// use logger level,
+	// default:
+	// 	zap.ErrorLevel: when the decision failed.
+	// 	zap.WarnLevel: when the decision is denied.
+	//  zap.InfoLevel: otherwise.
+	useLoggerLevel func(r *authj.AuditRecord) zapcore.Level
+}
+
+func useLoggerLevel(r *authj.AuditRecord) zapcore.Level {
+	switch {
+	case r.Err != nil:
+		return zap.ErrorLevel
+	case !r.Allowed:
+		return zap.WarnLevel
+	default:
+		return zap.InfoLevel
+	}
+}
+
+// Auditor logs the authorization decisions with zap, and the differences of the shadow.
+type Auditor struct {
+	logger *zap.Logger
+	cfg    Config
+}
+
+var (
+	_ authj.Auditor        = (*Auditor)(nil)
+	_ authj.ShadowReporter = (*Auditor)(nil)
+)
+
+// New returns an Auditor, use it with authj.WithAuditor and authj.NewShadow.
+func New(logger *zap.Logger, opts ...Option) *Auditor {
+	cfg := Config{
+		message:        "authorization",
+		useLoggerLevel: useLoggerLevel,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Auditor{logger: logger, cfg: cfg}
+}
+
+// Audit implement authj.Auditor interface.
+func (a *Auditor) Audit(c *gin.Context, r *authj.AuditRecord) {
+	fc := pool.Get()
+	defer pool.Put(fc)
+	fc.Fields = append(fc.Fields,
+		zap.String("traceId", r.TraceId),
+		zap.String("subject", r.Subject),
+		zap.Bool("allowed", r.Allowed),
+		zap.Any("args", r.Args),
+		zap.Strings("explain", r.Explain),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("route", c.FullPath()),
+		zap.String("ip", c.ClientIP()),
+		zap.Duration("latency", r.Latency),
+	)
+	for _, fieldFunc := range a.cfg.customFields {
+		fc.Fields = append(fc.Fields, fieldFunc(c))
+	}
+	if r.Err != nil {
+		fc.Fields = append(fc.Fields, zap.Error(r.Err))
+	}
+	a.logger.Log(a.cfg.useLoggerLevel(r), a.cfg.message, fc.Fields...)
+}
+
+// Report implement authj.ShadowReporter interface, the differences are logged at warn level.
+func (a *Auditor) Report(c *gin.Context, d *authj.ShadowDiff) {
+	fc := pool.Get()
+	defer pool.Put(fc)
+	fc.Fields = append(fc.Fields,
+		zap.String("traceId", d.TraceId),
+		zap.String("subject", d.Subject),
+		zap.Bool("allowed", d.Allowed),
+		zap.Bool("shadowAllowed", d.ShadowAllowed),
+		zap.Bool("promoted", d.Promoted),
+		zap.Any("args", d.Args),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("route", c.FullPath()),
+	)
+	for _, fieldFunc := range a.cfg.customFields {
+		fc.Fields = append(fc.Fields, fieldFunc(c))
+	}
+	if d.ShadowErr != nil {
+		fc.Fields = append(fc.Fields, zap.Error(d.ShadowErr))
+	}
+	a.logger.Warn(a.cfg.message+" shadow", fc.Fields...)
+}
@@ -0,0 +1,74 @@
+package authjzap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/things-go/gin-contrib/authj"
+	"github.com/things-go/gin-contrib/gzap"
+)
+
+func TestAuditor(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	auditor := New(zap.New(core), WithCustomFields(gzap.String("app", "example")))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, "/dataset1/item", http.NoBody)
+
+	auditor.Audit(c, &authj.AuditRecord{
+		Subject: "cathy",
+		Args:    []any{"cathy", "/dataset1/item", http.MethodGet},
+		Allowed: true,
+		Explain: []string{"dataset1_admin", "/dataset1/*", "*"},
+		Latency: time.Millisecond,
+		TraceId: "trace-1",
+	})
+	auditor.Audit(c, &authj.AuditRecord{Subject: "bob"})
+	auditor.Audit(c, &authj.AuditRecord{Subject: "bob", Err: errors.New("enforce failure")})
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	require.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	require.Equal(t, "authorization", entries[0].Message)
+	fields := entries[0].ContextMap()
+	require.Equal(t, "cathy", fields["subject"])
+	require.Equal(t, true, fields["allowed"])
+	require.Equal(t, "trace-1", fields["traceId"])
+	require.Equal(t, "/dataset1/item", fields["path"])
+	require.Equal(t, "example", fields["app"])
+	require.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	require.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+	require.Equal(t, "enforce failure", entries[2].ContextMap()["error"])
+}
+
+func TestAuditor_Report(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	auditor := New(zap.New(core))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPost, "/dataset1/resource1", http.NoBody)
+	auditor.Report(c, &authj.ShadowDiff{
+		Subject:       "alice",
+		Args:          []any{"alice", "/dataset1/resource1", http.MethodPost},
+		Allowed:       true,
+		ShadowAllowed: false,
+	})
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	require.Equal(t, "authorization shadow", entries[0].Message)
+	fields := entries[0].ContextMap()
+	require.Equal(t, true, fields["allowed"])
+	require.Equal(t, false, fields["shadowAllowed"])
+}
@@ -0,0 +1,139 @@
+package authorize
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/pem"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_Algorithm(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k1, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		alg string
+		key any
+	}{
+		{"PS256", rsaKey},
+		{"PS384", rsaKey},
+		{"PS512", rsaKey},
+		{"ES256K", k1},
+	} {
+		t.Run(tt.alg, func(t *testing.T) {
+			auth := newTestAuth(t, Config{Algorithm: tt.alg, PrivateKey: tt.key})
+
+			tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+			require.NoError(t, err)
+			claims, err := auth.ParseToken(tk)
+			require.NoError(t, err)
+			require.Equal(t, "alice", claims.Subject)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(tk, &jwt.RegisteredClaims{})
+			require.NoError(t, err)
+			require.Equal(t, tt.alg, parsed.Header["alg"])
+		})
+	}
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		_, err := New[*testAccount](Config{Algorithm: "RS265", PrivateKey: rsaKey})
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		_, err = New[*testAccount](Config{Algorithm: "none", Key: []byte("testSecretKey")})
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+}
+
+func TestAuth_ES256KKey(t *testing.T) {
+	k1, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	der, err := asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    k1.Serialize(),
+		NamedCurveOID: oidNamedCurveS256K,
+	})
+	require.NoError(t, err)
+	priv, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	require.True(t, k1.Key.Equals(&priv.(*secp256k1.PrivateKey).Key))
+
+	pub := k1.PubKey()
+	x, y := pub.X().Bytes(), pub.Y().Bytes()
+	jwkPub, err := ParsePublicKeyJWK([]byte(`{"kty":"EC","crv":"secp256k1","x":"` + b64(x) + `","y":"` + b64(y) + `"}`))
+	require.NoError(t, err)
+	require.True(t, pub.IsEqual(jwkPub.(*secp256k1.PublicKey)))
+
+	signer := newTestAuth(t, Config{Algorithm: "ES256K", PrivateKey: priv})
+	verifier := newTestAuth(t, Config{Algorithm: "ES256K", PrivateKey: priv, PublicKey: jwkPub})
+	tk, _, err := signer.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	_, err = verifier.ParseToken(tk)
+	require.NoError(t, err)
+}
+
+func TestAuth_AllowedAlgorithms(t *testing.T) {
+	privKey, pubKey := newTestRSAKey(t)
+	hsAuth := newTestAuth(t, Config{})
+
+	t.Run("migration", func(t *testing.T) {
+		auth := newTestAuth(t, Config{
+			Algorithm:         "RS256",
+			PrivKey:           privKey,
+			PubKey:            pubKey,
+			AllowedAlgorithms: []string{"RS256", "HS256"},
+		})
+		tk, _, err := hsAuth.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		claims, err := auth.ParseToken(tk)
+		require.NoError(t, err)
+		require.Equal(t, "alice", claims.Subject)
+
+		// the signing algorithm is not changed.
+		tk, _, err = auth.GenerateToken(newTestClaims("2", "bob"))
+		require.NoError(t, err)
+		_, err = auth.ParseToken(tk)
+		require.NoError(t, err)
+		_, err = hsAuth.ParseToken(tk)
+		require.Error(t, err)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		auth := newTestAuth(t, Config{Algorithm: "RS256", PrivKey: privKey})
+		tk, _, err := hsAuth.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		_, err = auth.ParseToken(tk)
+		require.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("missing verifying key", func(t *testing.T) {
+		_, err := New[*testAccount](Config{AllowedAlgorithms: []string{"ES256"}, Key: []byte("testSecretKey")})
+		require.ErrorIs(t, err, ErrInvalidPubKey)
+		_, err = New[*testAccount](Config{Algorithm: "RS256", PrivKey: privKey, AllowedAlgorithms: []string{"HS256"}})
+		require.ErrorIs(t, err, ErrMissingSecretKey)
+	})
+
+	t.Run("alg confusion", func(t *testing.T) {
+		auth := newTestAuth(t, Config{Algorithm: "RS256", PrivKey: privKey})
+		// HS256 token signed with the public key PEM as the secret.
+		forged := newTestAuth(t, Config{Key: []byte(pubKey)})
+		tk, _, err := forged.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		_, err = auth.ParseToken(tk)
+		require.Error(t, err)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		tk, err := jwt.NewWithClaims(jwt.SigningMethodNone, newTestClaims("1", "alice")).
+			SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		_, err = hsAuth.ParseToken(tk)
+		require.Error(t, err)
+	})
+}
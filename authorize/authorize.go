@@ -1,49 +1,191 @@
 package authorize
 
 import (
+	"context"
+	"crypto"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+)
+
+// TokenUse the kind of token.
+type TokenUse string
+
+// the kind of token.
+const (
+	// TokenUseAccess access token, empty token use is also treated as access token.
+	TokenUseAccess TokenUse = "access"
+	// TokenUseRefresh refresh token, which can only be exchanged for a new token pair.
+	TokenUseRefresh TokenUse = "refresh"
 )
 
 // Claims jwt claims
 type Claims[T any] struct {
 	jwt.RegisteredClaims
-	Meta T `json:"meta,omitempty"`
+	// TokenUse the kind of token, set by GenerateToken and GenerateRefreshToken.
+	TokenUse TokenUse `json:"token_use,omitempty"`
+	// Family the refresh token family, all tokens rotated from the same login share it.
+	Family string `json:"fam,omitempty"`
+	// Scope space-separated list of scopes granted to the token, see RFC 8693.
+	Scope string `json:"scope,omitempty"`
+	// Roles the roles of the subject.
+	Roles []string `json:"roles,omitempty"`
+	// ClientID the OAuth 2.0 client which the token is issued to, see RFC 9068.
+	ClientID string `json:"client_id,omitempty"`
+	// OrigIssuedAt the issue time of the original token, set by Renew and Refresh, the renewed
+	// or rotated token never lives longer than OrigIssuedAt + MaxTimeout.
+	OrigIssuedAt *jwt.NumericDate `json:"orig_iat,omitempty"`
+	// Confirmation binds the token to the key of the sender, see WithDPoP and WithMTLSBinding.
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	Meta         T             `json:"meta,omitempty"`
+}
+
+// confirmation returns the confirmation of the token, used by the non-generic token binding.
+func (c *Claims[T]) confirmation() *Confirmation { return c.Confirmation }
+
+// Scopes returns the list of scopes granted to the token.
+func (c *Claims[T]) Scopes() []string { return strings.Fields(c.Scope) }
+
+// HasScopes reports whether all the scopes are granted to the token.
+func (c *Claims[T]) HasScopes(scopes ...string) bool {
+	granted := c.Scopes()
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAnyScope reports whether any of the scopes is granted to the token.
+func (c *Claims[T]) HasAnyScope(scopes ...string) bool {
+	granted := c.Scopes()
+	return slices.ContainsFunc(scopes, func(scope string) bool {
+		return slices.Contains(granted, scope)
+	})
+}
+
+// HasRoles reports whether the subject has all the roles.
+func (c *Claims[T]) HasRoles(roles ...string) bool {
+	for _, role := range roles {
+		if !slices.Contains(c.Roles, role) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAnyRole reports whether the subject has any of the roles.
+func (c *Claims[T]) HasAnyRole(roles ...string) bool {
+	return slices.ContainsFunc(roles, func(role string) bool {
+		return slices.Contains(c.Roles, role)
+	})
 }
 
 // Config Auth config
 type Config struct {
 	// Timeout token valid time
-	// if timeout <= refreshTimeout, refreshTimeout = timeout + 30 * time.Minute
 	Timeout time.Duration
-	// RefreshTimeout refresh token valid time.
+	// RefreshTimeout refresh token valid time, it is the max lifetime of the token family,
+	// the tokens rotated by Refresh and renewed by Renew expire no later than the first issue time + RefreshTimeout.
+	// if RefreshTimeout <= Timeout, RefreshTimeout = Timeout + 30 * time.Minute
 	RefreshTimeout time.Duration
 	// Lookup used to extract token from the http request
 	// lookup is a string in the form of "<source>:<name>[:<prefix>]" that is used
 	// to extract value from the request.
 	// use like "header:<name>[:<prefix>],query:<name>,cookie:<name>,param:<name>"
 	// Optional, Default value "header:Authorization:Bearer" for json web token.
-	// Possible values:
+	// Possible values: see NewLookup, unknown source is reported as ErrInvalidLookup.
 	// - "header:<name>:<prefix>", <prefix> is a special string in the header, Possible value is "Bearer"
-	// - "query:<name>"
+	// - "query:<name>", the URL query or the POSTed form body
+	// - "form:<name>", the POSTed form body only
 	// - "cookie:<name>"
+	// - "param:<name>"
+	// - "basic:<username|password>"
+	// - "websocket:<marker>"
 	Lookup string
-	// 支持签名算法: HS256, HS384, HS512, RS256, RS384, RS512, EdDSA
+	// 支持签名算法: HS256, HS384, HS512, RS256, RS384, RS512, PS256, PS384, PS512,
+	// ES256, ES384, ES512, ES256K, EdDSA, the unknown algorithm is rejected with ErrUnsupportedAlgorithm.
 	// Optional, Default HS256.
 	Algorithm string
 	// Secret key used for signing.
 	// Required, if Algorithm is one of HS256, HS384, HS512.
 	Key []byte
+	// PrivateKey the private key for asymmetric algorithms, use LoadPrivateKeyFile, LoadPrivateKeyEnv,
+	// ParsePrivateKeyPEM, ParsePrivateKeyPKCS8 or ParsePrivateKeyJWK to load it.
+	// Required, if Algorithm is asymmetric and PrivKey is empty.
+	PrivateKey crypto.PrivateKey
+	// PublicKey the public key for asymmetric algorithms, use LoadPublicKeyFile, LoadPublicKeyEnv,
+	// ParsePublicKeyPEM or ParsePublicKeyJWK to load it.
+	// Optional, Default the public key of the PrivateKey or the Signer.
+	PublicKey crypto.PublicKey
 	// Private key for asymmetric algorithms,
 	// Public key for asymmetric algorithms
-	// Required, if Algorithm is one of RS256, RS384, RS512, EdDSA.
+	// they are base64, file path or PEM, which is guessed by trial.
+	// Deprecated: use PrivateKey and PublicKey instead.
 	PrivKey, PubKey string
+	// KeyID the id of the signing key, it is set as the `kid` header of the token.
+	// Optional, not used if Signer is set.
+	KeyID string
+	// Signer signs the token instead of the key, such as KMS or HSM, see NewCryptoSigner.
+	// Algorithm, Key, PrivateKey and PrivKey are ignored if it is set.
+	// the token is verified by PublicKey, PubKey, or the public key of the Signer if it has a
+	// `Public() crypto.PublicKey` method.
+	// Optional.
+	Signer Signer
+	// AllowedAlgorithms the algorithms accepted when verifying the token, such as accepting both
+	// HS256 and RS256 while migrating between them, tokens are always signed with Algorithm.
+	// the HMAC algorithms are verified by Key, the asymmetric ones by PublicKey, PubKey or VerifyingKeys.
+	// Optional, Default only the signing algorithm.
+	AllowedAlgorithms []string
+	// VerifyingKeys the additional public keys of the other AllowedAlgorithms,
+	// the first one which matches the algorithm is used.
+	// Optional.
+	VerifyingKeys []crypto.PublicKey
 	// the issuer of the jwt
 	Issuer string
+	// Audience the audience of the issued jwt, used only if the claims have no audience.
+	// Optional.
+	Audience []string
+	// ValidIssuers the expected issuers, the `iss` of the token must be one of them.
+	// Optional, if it is empty, the issuer is not checked.
+	ValidIssuers []string
+	// ValidAudiences the required audiences, the `aud` of the token must contain at least one of them.
+	// Optional, if it is empty, the audience is not checked.
+	ValidAudiences []string
+	// Leeway allowed clock skew when validating the `exp`, `nbf` and `iat` of the token.
+	// Optional, Default 0.
+	Leeway time.Duration
+	// RequiredClaims the registered claims which must be present in the token.
+	// Optional, Possible values: "iss", "sub", "aud", "exp", "nbf", "iat", "jti".
+	RequiredClaims []string
+	// SubjectCodec encodes the subject and the token id into the `sub` claim.
+	// Optional, Default TokenSubjectCodec, use PlainSubjectCodec to keep the `sub` readable by other jwt consumers.
+	SubjectCodec SubjectCodec
+	// SkipConnIdCheck skips ValidateConnId, which checks the connId of the decoded subject is the token id.
+	// Optional, Default false, it must be true with the SubjectCodec which does not encode the connId, such as PlainSubjectCodec,
+	// otherwise New returns ErrInvalidSubjectCodec.
+	SkipConnIdCheck bool
+	// SubjectValidator validates the decoded subject after ValidateConnId.
+	// Optional, Default no validation.
+	SubjectValidator SubjectValidator
+	// Encryption encrypts the signed token into a JWE, so the claims are not readable by the client.
+	// ParseToken and ParseFromRequest decrypt it transparently, and reject tokens which are not encrypted.
+	// Optional, if it is nil, tokens are only signed.
+	Encryption *EncryptionConfig
+	// RevocationStore used to revoke tokens before they expire.
+	// Optional, if it is nil, tokens can not be revoked.
+	RevocationStore revocation.Store
+	// FamilyStore used to rotate refresh tokens and detect reuse of them.
+	// Optional, if it is nil, refresh tokens are not single-use.
+	FamilyStore revocation.FamilyStore
 }
 
 // Auth provides a Json-Web-Token authentication implementation.
@@ -52,63 +194,170 @@ type Auth[T any] struct {
 	refreshTimeout time.Duration
 	lookup         *Lookup
 	signingMethod  jwt.SigningMethod
-	encodeKey      any
-	decodeKey      any
+	signer         Signer
+	algorithms     []string
+	verifyingKeys  map[string]any
 	issuer         string
+	audience       []string
+	validation     validation
+	subjectCodec   SubjectCodec
+	subjectCheck   SubjectValidator
+	encrypter      *encrypter
+	revocation     revocation.Store
+	families       revocation.FamilyStore
 }
 
 // New auth with Config
 func New[T any](c Config) (*Auth[T], error) {
-	var err error
-
+	lookup, err := ParseLookup(c.Lookup)
+	if err != nil {
+		return nil, err
+	}
 	mw := &Auth[T]{
 		timeout:        c.Timeout,
 		refreshTimeout: c.RefreshTimeout,
-		lookup:         NewLookup(c.Lookup),
+		lookup:         lookup,
+		issuer:         c.Issuer,
+		audience:       c.Audience,
+		validation: validation{
+			issuers:        c.ValidIssuers,
+			audiences:      c.ValidAudiences,
+			leeway:         c.Leeway,
+			requiredClaims: c.RequiredClaims,
+		},
+		subjectCodec: c.SubjectCodec,
+		subjectCheck: subjectValidator(c.SkipConnIdCheck, c.SubjectValidator),
+		revocation:   c.RevocationStore,
+		families:     c.FamilyStore,
+	}
+	if mw.subjectCodec == nil {
+		mw.subjectCodec = TokenSubjectCodec
 	}
-	if mw.timeout <= mw.refreshTimeout {
+	if !c.SkipConnIdCheck && !encodesConnId(mw.subjectCodec) {
+		return nil, ErrInvalidSubjectCodec
+	}
+	if mw.refreshTimeout <= mw.timeout {
 		mw.refreshTimeout = mw.timeout + 30*time.Minute
 	}
-	switch c.Algorithm {
-	case "ES256", "ES384", "ES512":
-		mw.encodeKey, err = parseECPrivateKey(c.PrivKey)
+	if err = mw.validation.check(); err != nil {
+		return nil, err
+	}
+	if c.Encryption != nil {
+		mw.encrypter, err = newEncrypter(c.Encryption)
 		if err != nil {
-			return nil, ErrInvalidPrivKey
+			return nil, err
 		}
-		mw.decodeKey, err = parseECPublicKey(c.PubKey)
+	}
+	mw.signer = c.Signer
+	if mw.signer == nil {
+		mw.signer, err = newConfigSigner(&c)
 		if err != nil {
-			return nil, ErrInvalidPubKey
+			return nil, err
 		}
-	case "RS256", "RS512", "RS384":
-		mw.encodeKey, err = parseRSAPrivateKey(c.PrivKey)
-		if err != nil {
-			return nil, ErrInvalidPrivKey
+	}
+	mw.signingMethod, err = getSigningMethod(mw.signer.Algorithm())
+	if err != nil {
+		return nil, err
+	}
+	decodeKey, err := verifyingKey(&c, mw.signingMethod, mw.signer)
+	if err != nil {
+		return nil, err
+	}
+	mw.algorithms, mw.verifyingKeys, err = allowedKeys(&c, mw.signingMethod, decodeKey)
+	if err != nil {
+		return nil, err
+	}
+	return mw, nil
+}
+
+// allowedKeys returns the allowed algorithms and the verifying key of each of them.
+func allowedKeys(c *Config, signing jwt.SigningMethod, decodeKey any) ([]string, map[string]any, error) {
+	algorithms := []string{signing.Alg()}
+	keys := map[string]any{signing.Alg(): decodeKey}
+	for _, alg := range c.AllowedAlgorithms {
+		if _, ok := keys[alg]; ok {
+			continue
 		}
-		mw.decodeKey, err = parseRSAPublicKey(c.PubKey)
+		method, err := getSigningMethod(alg)
 		if err != nil {
-			return nil, ErrInvalidPubKey
+			return nil, nil, err
+		}
+		var key any
+		if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+			if len(c.Key) == 0 {
+				return nil, nil, fmt.Errorf("%w: %s is allowed", ErrMissingSecretKey, alg)
+			}
+			key = c.Key
+		} else {
+			for _, k := range append([]crypto.PublicKey{decodeKey}, c.VerifyingKeys...) {
+				if checkVerifyingKey(method, k) == nil {
+					key = k
+					break
+				}
+			}
+			if key == nil {
+				return nil, nil, fmt.Errorf("%w: no verifying key for %s", ErrInvalidPubKey, alg)
+			}
 		}
-	case "EdDSA":
-		mw.encodeKey, err = parseEdPrivateKey(c.PrivKey)
+		algorithms = append(algorithms, alg)
+		keys[alg] = key
+	}
+	return algorithms, keys, nil
+}
+
+// newConfigSigner new Signer with the key of Config.
+func newConfigSigner(c *Config) (Signer, error) {
+	alg := c.Algorithm
+	if alg == "" {
+		alg = "HS256"
+	}
+	method, err := getSigningMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if len(c.Key) == 0 {
+			return nil, ErrMissingSecretKey
+		}
+		return NewKeySigner(alg, c.Key, c.KeyID)
+	}
+	key := c.PrivateKey
+	if key == nil {
+		key, err = parsePrivKey(method, c.PrivKey)
 		if err != nil {
 			return nil, ErrInvalidPrivKey
 		}
-		mw.decodeKey, err = parseEdPublicKey(c.PubKey)
+	}
+	return NewKeySigner(alg, key, c.KeyID)
+}
+
+// verifyingKey returns the key which verifies the token.
+func verifyingKey(c *Config, method jwt.SigningMethod, signer Signer) (any, error) {
+	var key any
+
+	switch {
+	case c.PublicKey != nil:
+		key = c.PublicKey
+	case c.PubKey != "":
+		var err error
+
+		key, err = parsePubKey(method, c.PubKey)
 		if err != nil {
 			return nil, ErrInvalidPubKey
 		}
-	default: // "HS256", "HS512", "HS384" or empty string
-		if c.Key == nil {
-			return nil, ErrMissingSecretKey
-		}
-		if !slices.Contains([]string{"HS256", "HS512", "HS384"}, c.Algorithm) {
-			c.Algorithm = "HS256"
+	default:
+		if _, ok := method.(*jwt.SigningMethodHMAC); ok && c.Key != nil {
+			key = c.Key
+		} else if s, ok := signer.(interface{ Public() crypto.PublicKey }); ok {
+			key = s.Public()
+		} else {
+			return nil, fmt.Errorf("%w: public key is required", ErrInvalidPubKey)
 		}
-		mw.encodeKey = c.Key
-		mw.decodeKey = c.Key
 	}
-	mw.signingMethod = jwt.GetSigningMethod(c.Algorithm)
-	return mw, nil
+	if err := checkVerifyingKey(method, key); err != nil {
+		return nil, err
+	}
+	return key, nil
 }
 
 // Timeout token valid time
@@ -119,12 +368,36 @@ func (a *Auth[T]) MaxTimeout() time.Duration { return a.refreshTimeout }
 
 // ParseToken parse token
 func (p *Auth[T]) ParseToken(tokenString string) (*Claims[T], error) {
+	return p.ParseTokenWithContext(context.Background(), tokenString)
+}
+
+// ParseTokenWithContext parse token, the ctx is used to check the revocation store.
+// refresh token is rejected with ErrTokenUseMismatch.
+func (p *Auth[T]) ParseTokenWithContext(ctx context.Context, tokenString string) (*Claims[T], error) {
+	return p.parseToken(ctx, tokenString, TokenUseAccess)
+}
+
+// ParseRefreshToken parse refresh token, access token is rejected with ErrTokenUseMismatch.
+func (p *Auth[T]) ParseRefreshToken(ctx context.Context, tokenString string) (*Claims[T], error) {
+	return p.parseToken(ctx, tokenString, TokenUseRefresh)
+}
+
+func (p *Auth[T]) parseToken(ctx context.Context, tokenString string, use TokenUse) (*Claims[T], error) {
+	var err error
+
+	if p.encrypter != nil {
+		tokenString, err = p.encrypter.Decrypt(tokenString)
+		if err != nil {
+			return nil, err
+		}
+	}
 	tk, err := jwt.ParseWithClaims(tokenString, &Claims[T]{}, func(t *jwt.Token) (any, error) {
-		if p.signingMethod != t.Method {
+		key, ok := p.verifyingKeys[t.Method.Alg()]
+		if !ok {
 			return nil, jwt.ErrTokenSignatureInvalid
 		}
-		return p.decodeKey, nil
-	})
+		return key, nil
+	}, jwt.WithLeeway(p.validation.leeway), jwt.WithValidMethods(p.algorithms))
 	if err != nil {
 		return nil, fmt.Errorf("token parser failure, %w", err)
 	}
@@ -135,31 +408,71 @@ func (p *Auth[T]) ParseToken(tokenString string) (*Claims[T], error) {
 	if !ok || claims == nil {
 		return nil, jwt.ErrTokenInvalidClaims
 	}
+	if err = p.validation.validate(&claims.RegisteredClaims); err != nil {
+		return nil, err
+	}
 	if claims.Subject == "" {
-		return nil, jwt.ErrTokenNotValidYet
+		return nil, &ClaimError{Claim: "sub", Err: ErrMissingClaim}
 	}
-	ts := TokenSubject{}
-	err = Unmarshal(claims.Subject, &ts)
+	ts, err := p.subjectCodec.Decode(claims.Subject)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid subject, %w", ErrTokenMalformed, err)
 	}
-	if ts.ConnId != claims.ID {
-		return nil, jwt.ErrTokenInvalidId
+	if p.subjectCheck != nil {
+		if err = p.subjectCheck(ts, &claims.RegisteredClaims); err != nil {
+			return nil, err
+		}
 	}
 	claims.Subject = ts.Sub
+	if claims.OrigIssuedAt != nil &&
+		time.Now().After(claims.OrigIssuedAt.Add(p.refreshTimeout+p.validation.leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if claims.TokenUse != use && (use != TokenUseAccess || claims.TokenUse != "") {
+		return nil, ErrTokenUseMismatch
+	}
+	if err = p.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
 	return claims, nil
 }
 
 // GenerateToken generate token
 func (a *Auth[T]) GenerateToken(val *Claims[T]) (string, time.Time, error) {
+	val.TokenUse = TokenUseAccess
 	return a.generateToken(val, a.timeout)
 }
 
-// GenerateRefreshToken generate refresh token
+// GenerateRefreshToken generate refresh token.
+// NOTE: if FamilyStore is set, use GenerateTokenPair instead, the refresh token
+// must be recorded in the FamilyStore to be exchanged, otherwise Refresh rejects it
+// with ErrRefreshTokenNoFamily, and the one with an unrecorded val.Family is taken as reused.
 func (a *Auth[T]) GenerateRefreshToken(val *Claims[T]) (string, time.Time, error) {
+	val.TokenUse = TokenUseRefresh
 	return a.generateToken(val, a.refreshTimeout)
 }
 
+// Renew generates a fresh token of the claims, which should be the result of ParseToken.
+// the original issue time is kept in OrigIssuedAt, and the fresh token expires no later than
+// the original issue time + MaxTimeout, returns ErrTokenExpired if it is already reached.
+func (a *Auth[T]) Renew(claims *Claims[T]) (string, time.Time, error) {
+	origIssuedAt := claims.OrigIssuedAt
+	if origIssuedAt == nil {
+		origIssuedAt = claims.IssuedAt
+	}
+	if origIssuedAt == nil {
+		return "", time.Time{}, &ClaimError{Claim: "iat", Err: ErrMissingClaim}
+	}
+	timeout := min(a.timeout, time.Until(origIssuedAt.Add(a.refreshTimeout)))
+	if timeout <= 0 {
+		return "", time.Time{}, ErrTokenExpired
+	}
+	val := *claims
+	val.OrigIssuedAt = origIssuedAt
+	val.TokenUse = TokenUseAccess
+	return a.generateToken(&val, timeout)
+}
+
 // ExtractToken extract token from http request
 func (a *Auth[T]) ExtractToken(r *http.Request) (string, error) {
 	return a.lookup.ExtractToken(r)
@@ -171,11 +484,87 @@ func (a *Auth[T]) ParseFromRequest(r *http.Request) (*Claims[T], error) {
 	if err != nil {
 		return nil, err
 	}
-	return a.ParseToken(token)
+	return a.ParseTokenWithContext(r.Context(), token)
+}
+
+// Revoke revokes the token which the claims belong to, the claims should be
+// the result of ParseToken.
+// the renewed tokens share the jti, so the mark is kept until the original issue time + MaxTimeout,
+// which is the latest expiry of all renewed tokens.
+func (a *Auth[T]) Revoke(ctx context.Context, claims *Claims[T]) error {
+	if a.revocation == nil {
+		return ErrMissingRevocationStore
+	}
+	if claims.ID == "" {
+		return jwt.ErrTokenInvalidId
+	}
+	expiresAt := time.Now().Add(a.refreshTimeout)
+	origIssuedAt := claims.OrigIssuedAt
+	if origIssuedAt == nil {
+		origIssuedAt = claims.IssuedAt
+	}
+	if origIssuedAt != nil {
+		expiresAt = origIssuedAt.Add(a.refreshTimeout)
+	}
+	if claims.ExpiresAt != nil && claims.ExpiresAt.After(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return a.revocation.Revoke(ctx, claims.ID, expiresAt)
+}
+
+// RevokeSubject revokes all tokens of the subject issued before t,
+// such as logout everywhere, password change or account ban.
+// NOTE: t is truncated to seconds as the issue time of the tokens, the token issued in the same second
+// of t is also revoked, so the re-login right after the revocation should retry in the next second.
+func (a *Auth[T]) RevokeSubject(ctx context.Context, subject string, t time.Time) error {
+	if a.revocation == nil {
+		return ErrMissingRevocationStore
+	}
+	// tokens issued before t are all expired after t + MaxTimeout.
+	return a.revocation.RevokeSubject(ctx, subject, t, t.Add(a.refreshTimeout))
+}
+
+func (a *Auth[T]) checkRevoked(ctx context.Context, claims *Claims[T]) error {
+	if err := a.checkFamilyRevoked(ctx, claims); err != nil {
+		return err
+	}
+	if a.revocation == nil {
+		return nil
+	}
+	revoked, err := a.revocation.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	before, err := a.revocation.RevokedBefore(ctx, claims.Subject)
+	if err != nil {
+		return err
+	}
+	// iat is in seconds, fail closed, the token issued in the same second of the revocation is revoked.
+	if !before.IsZero() && (claims.IssuedAt == nil || !claims.IssuedAt.After(before.Truncate(time.Second))) {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+func (a *Auth[T]) checkFamilyRevoked(ctx context.Context, claims *Claims[T]) error {
+	if a.families == nil || claims.Family == "" {
+		return nil
+	}
+	revoked, err := a.families.IsFamilyRevoked(ctx, claims.Family)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
 }
 
 func (p *Auth[T]) generateToken(val *Claims[T], timeout time.Duration) (string, time.Time, error) {
-	sub, err := Marshal(&TokenSubject{
+	sub, err := p.subjectCodec.Encode(TokenSubject{
 		Sub:    val.Subject,
 		ConnId: val.ID,
 	})
@@ -184,12 +573,40 @@ func (p *Auth[T]) generateToken(val *Claims[T], timeout time.Duration) (string,
 	}
 	now := time.Now()
 	expiresAt := now.Add(timeout)
+	if val.OrigIssuedAt != nil && val.OrigIssuedAt.Add(p.refreshTimeout).Before(expiresAt) {
+		expiresAt = val.OrigIssuedAt.Add(p.refreshTimeout)
+	}
 	val.Issuer = p.issuer
+	if len(val.Audience) == 0 {
+		val.Audience = p.audience
+	}
 	val.ExpiresAt = jwt.NewNumericDate(expiresAt)
 	val.NotBefore = jwt.NewNumericDate(now)
 	val.IssuedAt = jwt.NewNumericDate(now)
 	val.Subject = sub
-	token, err := jwt.NewWithClaims(p.signingMethod, val).
-		SignedString(p.encodeKey)
+	token, err := p.sign(val)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if p.encrypter != nil {
+		token, err = p.encrypter.Encrypt(token)
+	}
 	return token, expiresAt, err
 }
+
+// sign signs the claims with the Signer.
+func (p *Auth[T]) sign(val *Claims[T]) (string, error) {
+	tk := jwt.NewWithClaims(p.signingMethod, val)
+	if kid := p.signer.KeyID(); kid != "" {
+		tk.Header["kid"] = kid
+	}
+	signingString, err := tk.SigningString()
+	if err != nil {
+		return "", err
+	}
+	sig, err := p.signer.Sign([]byte(signingString))
+	if err != nil {
+		return "", fmt.Errorf("sign token failure, %w", err)
+	}
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
@@ -0,0 +1,251 @@
+package authorize
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CookieConfig the config of CookieSession.
+type CookieConfig struct {
+	// AccessName the cookie name of the access token.
+	// Optional, Default "access_token".
+	// NOTE: Config.Lookup should contain "cookie:<AccessName>", so Middleware can extract the token from the cookie.
+	AccessName string
+	// RefreshName the cookie name of the refresh token.
+	// Optional, Default "refresh_token".
+	RefreshName string
+	// RefreshPath the path of the refresh token cookie, limits the cookie to the refresh endpoint.
+	// Optional, Default Path.
+	RefreshPath string
+	// CSRFName the cookie name of the CSRF token, which is readable by javascript.
+	// Optional, Default "csrf_token".
+	CSRFName string
+	// CSRFHeader the header name which the client echoes the CSRF token in.
+	// Optional, Default "X-CSRF-Token".
+	CSRFHeader string
+	// Domain the domain of the cookies.
+	// Optional.
+	Domain string
+	// Path the path of the cookies.
+	// Optional, Default "/".
+	Path string
+	// Insecure disables the Secure attribute of the cookies, only used in local development with http.
+	// Optional, Default false.
+	Insecure bool
+	// SameSite the SameSite attribute of the cookies.
+	// Optional, Default http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// RenewWithin renews the access token cookie when it expires within the duration, see Renew.
+	// Optional, Default 0, disable renewal.
+	RenewWithin time.Duration
+}
+
+// CookieSession issues the tokens as `HttpOnly; Secure; SameSite` cookies,
+// and protects the cookie authenticated requests from CSRF with the double-submit cookie pattern.
+type CookieSession[T any] struct {
+	auth *Auth[T]
+	cfg  CookieConfig
+}
+
+// NewCookieSession new cookie session with CookieConfig
+func (a *Auth[T]) NewCookieSession(cfg CookieConfig) *CookieSession[T] {
+	if cfg.AccessName == "" {
+		cfg.AccessName = "access_token"
+	}
+	if cfg.RefreshName == "" {
+		cfg.RefreshName = "refresh_token"
+	}
+	if cfg.CSRFName == "" {
+		cfg.CSRFName = "csrf_token"
+	}
+	if cfg.CSRFHeader == "" {
+		cfg.CSRFHeader = "X-CSRF-Token"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.RefreshPath == "" {
+		cfg.RefreshPath = cfg.Path
+	}
+	if cfg.SameSite == 0 {
+		cfg.SameSite = http.SameSiteLaxMode
+	}
+	return &CookieSession[T]{a, cfg}
+}
+
+// Login generates a token pair, sets the token cookies and a new CSRF token cookie.
+func (s *CookieSession[T]) Login(c *gin.Context, val *Claims[T]) (*TokenPair, error) {
+	pair, err := s.auth.GenerateTokenPair(c.Request.Context(), val)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.SetTokenCookies(c, pair); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// SetTokenCookies sets the token cookies of the pair and a new CSRF token cookie.
+func (s *CookieSession[T]) SetTokenCookies(c *gin.Context, pair *TokenPair) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+	s.setCookie(c, s.cfg.AccessName, s.cfg.Path, pair.AccessToken, pair.AccessExpiresAt, true)
+	s.setCookie(c, s.cfg.RefreshName, s.cfg.RefreshPath, pair.RefreshToken, pair.RefreshExpiresAt, true)
+	s.setCookie(c, s.cfg.CSRFName, s.cfg.Path, csrfToken, pair.RefreshExpiresAt, false)
+	return nil
+}
+
+// Logout clears the token cookies. if there are claims put by Middleware in the context,
+// revokes the token when the RevocationStore is set, and its family when the FamilyStore is set.
+func (s *CookieSession[T]) Logout(c *gin.Context) error {
+	s.clearCookie(c, s.cfg.AccessName, s.cfg.Path, true)
+	s.clearCookie(c, s.cfg.RefreshName, s.cfg.RefreshPath, true)
+	s.clearCookie(c, s.cfg.CSRFName, s.cfg.Path, false)
+
+	ctx := c.Request.Context()
+	claims, ok := FromContext[T](ctx)
+	if !ok || claims == nil {
+		return nil
+	}
+	if s.auth.revocation != nil {
+		if err := s.auth.Revoke(ctx, claims); err != nil {
+			return err
+		}
+	}
+	return s.auth.RevokeFamily(ctx, claims)
+}
+
+// RefreshHandler returns a handler which exchanges the refresh token cookie for a new token pair,
+// sets the new token cookies, and responds 204 No Content.
+// the request must pass the CSRF check, as it is authenticated by the cookie.
+// the unauthorized fallback of opts is used when the refresh token is missing or invalid.
+func (s *CookieSession[T]) RefreshHandler(opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts...)
+	return func(c *gin.Context) {
+		if !s.validCSRF(c) {
+			abortWithCSRFError(c)
+			return
+		}
+		refreshToken, err := CookieExtractor(s.cfg.RefreshName).ExtractToken(c.Request)
+		if err != nil {
+			o.unauthorizedFallback(c, err)
+			c.Abort()
+			return
+		}
+		pair, err := s.auth.Refresh(c.Request.Context(), refreshToken)
+		if err != nil {
+			o.unauthorizedFallback(c, err)
+			c.Abort()
+			return
+		}
+		if err = s.SetTokenCookies(c, pair); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Renew returns a middleware which renews the access token cookie when it expires within RenewWithin,
+// it should be used after Middleware, and only the token which comes from the cookie is renewed.
+// see Auth.Renew.
+func (s *CookieSession[T]) Renew() gin.HandlerFunc {
+	writer := s.RenewalCookie()
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		claims, ok := FromContext[T](ctx)
+		if ok && claims != nil && IsFromCookie(ctx) && needRenew(claims, s.cfg.RenewWithin) {
+			if token, expiresAt, err := s.auth.Renew(claims); err == nil {
+				writer(c, token, expiresAt)
+			}
+		}
+		c.Next()
+	}
+}
+
+// RenewalCookie returns a RenewalWriter which writes the renewed token to the access token cookie,
+// it can be used with WithRenewal.
+func (s *CookieSession[T]) RenewalCookie() RenewalWriter {
+	return func(c *gin.Context, token string, expiresAt time.Time) {
+		s.setCookie(c, s.cfg.AccessName, s.cfg.Path, token, expiresAt, true)
+	}
+}
+
+// CSRF returns a middleware which protects from CSRF with the double-submit cookie pattern,
+// it should be used after Middleware. it is enforced only when the token comes from the cookie,
+// and the request method is not safe, the CSRF header must be equal to the CSRF cookie,
+// otherwise responds 403.
+func (s *CookieSession[T]) CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsFromCookie(c.Request.Context()) && !isSafeMethod(c.Request.Method) && !s.validCSRF(c) {
+			abortWithCSRFError(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *CookieSession[T]) validCSRF(c *gin.Context) bool {
+	cookie, err := CookieExtractor(s.cfg.CSRFName).ExtractToken(c.Request)
+	if err != nil {
+		return false
+	}
+	header := c.GetHeader(s.cfg.CSRFHeader)
+	return header != "" && subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func (s *CookieSession[T]) setCookie(c *gin.Context, name, path, value string, expiresAt time.Time, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.cfg.Domain,
+		Expires:  expiresAt,
+		MaxAge:   max(int(time.Until(expiresAt).Seconds()), 1),
+		Secure:   !s.cfg.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: s.cfg.SameSite,
+	})
+}
+
+func (s *CookieSession[T]) clearCookie(c *gin.Context, name, path string, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     path,
+		Domain:   s.cfg.Domain,
+		MaxAge:   -1,
+		Secure:   !s.cfg.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: s.cfg.SameSite,
+	})
+}
+
+func abortWithCSRFError(c *gin.Context) {
+	c.String(http.StatusForbidden, ErrInvalidCSRFToken.Error())
+	c.Abort()
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
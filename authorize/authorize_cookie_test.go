@@ -0,0 +1,111 @@
+package authorize
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize/revocation/memory"
+)
+
+func testCookieRequest(router http.Handler, method, path string, cookies []*http.Cookie, headers map[string]string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequestWithContext(context.TODO(), method, path, http.NoBody)
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+// findCookie returns the last cookie with the name, as the later one overrides the former.
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	var found *http.Cookie
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			found = cookie
+		}
+	}
+	return found
+}
+
+func TestCookieSession(t *testing.T) {
+	store := memory.NewStore(cache.New(time.Hour, time.Minute))
+	auth := newTestAuth(t, Config{
+		Lookup:          "header:Authorization:Bearer,cookie:access_token",
+		RevocationStore: store,
+		FamilyStore:     store,
+	})
+	session := auth.NewCookieSession(CookieConfig{RenewWithin: 2 * time.Hour})
+
+	router := gin.New()
+	router.POST("/login", func(c *gin.Context) {
+		_, err := session.Login(c, newTestClaims("", "alice"))
+		require.NoError(t, err)
+	})
+	router.POST("/refresh", session.RefreshHandler())
+	authorized := router.Group("/", auth.Middleware(), session.CSRF(), session.Renew())
+	authorized.GET("/me", func(c *gin.Context) {})
+	authorized.POST("/me", func(c *gin.Context) {})
+	authorized.POST("/logout", func(c *gin.Context) {
+		require.NoError(t, session.Logout(c))
+	})
+
+	w := testCookieRequest(router, http.MethodPost, "/login", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+	accessCookie := findCookie(cookies, "access_token")
+	require.NotNil(t, accessCookie)
+	require.True(t, accessCookie.HttpOnly)
+	require.True(t, accessCookie.Secure)
+	require.Equal(t, http.SameSiteLaxMode, accessCookie.SameSite)
+	require.NotNil(t, findCookie(cookies, "refresh_token"))
+	csrfCookie := findCookie(cookies, "csrf_token")
+	require.NotNil(t, csrfCookie)
+	require.False(t, csrfCookie.HttpOnly)
+	csrfHeader := map[string]string{"X-CSRF-Token": csrfCookie.Value}
+
+	// safe method does not need the CSRF token, and renews the access token cookie.
+	w = testCookieRequest(router, http.MethodGet, "/me", cookies, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, findCookie(w.Result().Cookies(), "access_token"))
+
+	// unsafe method with cookie needs the CSRF token.
+	w = testCookieRequest(router, http.MethodPost, "/me", cookies, nil)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	w = testCookieRequest(router, http.MethodPost, "/me", cookies, map[string]string{"X-CSRF-Token": "foo"})
+	require.Equal(t, http.StatusForbidden, w.Code)
+	w = testCookieRequest(router, http.MethodPost, "/me", cookies, csrfHeader)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// token from header is not checked.
+	w = testCookieRequest(router, http.MethodPost, "/me", nil, map[string]string{"Authorization": "Bearer " + accessCookie.Value})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// refresh
+	w = testCookieRequest(router, http.MethodPost, "/refresh", cookies, nil)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	w = testCookieRequest(router, http.MethodPost, "/refresh", cookies, csrfHeader)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	refreshedCookies := w.Result().Cookies()
+	require.NotEqual(t, accessCookie.Value, findCookie(refreshedCookies, "access_token").Value)
+	refreshedCSRFHeader := map[string]string{"X-CSRF-Token": findCookie(refreshedCookies, "csrf_token").Value}
+
+	// logout clears the cookies and revokes the tokens.
+	w = testCookieRequest(router, http.MethodPost, "/logout", refreshedCookies, refreshedCSRFHeader)
+	require.Equal(t, http.StatusOK, w.Code)
+	for _, name := range []string{"access_token", "refresh_token", "csrf_token"} {
+		require.Equal(t, -1, findCookie(w.Result().Cookies(), name).MaxAge, name)
+	}
+	w = testCookieRequest(router, http.MethodGet, "/me", refreshedCookies, nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -1,7 +1,8 @@
 package authorize
 
 import (
-	"net/http"
+	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,6 +14,22 @@ type Option func(*options)
 type options struct {
 	skip                 func(c *gin.Context) bool
 	unauthorizedFallback func(*gin.Context, error)
+	realm                string
+	problemDetails       bool
+	renewWithin          time.Duration
+	renewalWriter        RenewalWriter
+	hooks                []ClaimsHook
+	optional             bool
+}
+
+// RenewalWriter writes the renewed token back to the client.
+type RenewalWriter func(c *gin.Context, token string, expiresAt time.Time)
+
+// RenewalHeader returns a RenewalWriter which writes the renewed token to the response header.
+func RenewalHeader(name string) RenewalWriter {
+	return func(c *gin.Context, token string, _ time.Time) {
+		c.Header(name, token)
+	}
 }
 
 // WithSkip set skip func
@@ -25,6 +42,8 @@ func WithSkip(f func(c *gin.Context) bool) Option {
 }
 
 // WithUnauthorizedFallback sets the fallback handler when requests are unauthorized.
+// default: responds RFC 6750 error with AbortWithBearerError, see WithRealm and WithProblemDetails.
+// use NewBearerError to classify the error.
 func WithUnauthorizedFallback(f func(c *gin.Context, err error)) Option {
 	return func(o *options) {
 		if f != nil {
@@ -33,26 +52,116 @@ func WithUnauthorizedFallback(f func(c *gin.Context, err error)) Option {
 	}
 }
 
-func (sf *Auth[T]) Middleware(opts ...Option) gin.HandlerFunc {
+// WithRealm sets the realm of the `WWW-Authenticate` header of the default unauthorized fallback.
+func WithRealm(realm string) Option {
+	return func(o *options) {
+		o.realm = realm
+	}
+}
+
+// WithProblemDetails sets whether the default unauthorized fallback responds
+// RFC 7807 problem details json body, default: false, responds plain text.
+func WithProblemDetails(b bool) Option {
+	return func(o *options) {
+		o.problemDetails = b
+	}
+}
+
+// WithRenewal sets the sliding session renewal, when a valid token expires within the duration,
+// a fresh token is issued with Auth.Renew and written by the writer, the session never lives longer
+// than the original issue time + MaxTimeout.
+// writer default: RenewalHeader("X-Renewed-Token").
+func WithRenewal(within time.Duration, writer RenewalWriter) Option {
+	return func(o *options) {
+		o.renewWithin = within
+		o.renewalWriter = writer
+		if writer == nil {
+			o.renewalWriter = RenewalHeader("X-Renewed-Token")
+		}
+	}
+}
+
+// WithOptional sets whether the authentication is optional, default false.
+// if it is optional, the request without token continues anonymously, but the request
+// with an invalid token is still rejected. use IsAuthenticated to check whether
+// the request was authenticated.
+func WithOptional(b bool) Option {
+	return func(o *options) {
+		o.optional = b
+	}
+}
+
+func newOptions(opts ...Option) *options {
 	o := &options{
-		unauthorizedFallback: func(c *gin.Context, err error) {
-			c.String(http.StatusUnauthorized, err.Error())
-		},
 		skip: func(c *gin.Context) bool { return false },
 	}
 	for _, opt := range opts {
 		opt(o)
 	}
+	if o.unauthorizedFallback == nil {
+		o.unauthorizedFallback = func(c *gin.Context, err error) {
+			AbortWithBearerError(c, err, o.realm, o.problemDetails)
+		}
+	}
+	return o
+}
+
+// Middleware returns a middleware which authenticates the request with the token extracted by Config.Lookup.
+func (sf *Auth[T]) Middleware(opts ...Option) gin.HandlerFunc {
+	return NewMiddleware[T](sf.lookup, sf, opts...)
+}
+
+// NewMiddleware returns a middleware which authenticates the request with the token extracted by lookup,
+// and validated by validator, such as an opaque token introspection client.
+// lookup default: "header:Authorization:Bearer".
+// the renewal of WithRenewal only works when the validator can renew the token, such as Auth.
+func NewMiddleware[T any](lookup *Lookup, validator Validator[T], opts ...Option) gin.HandlerFunc {
+	if lookup == nil {
+		lookup = NewLookup("")
+	}
+	o := newOptions(opts...)
+	renew, _ := validator.(renewer[T])
 	return func(c *gin.Context) {
 		if !o.skip(c) {
-			acc, err := sf.ParseFromRequest(c.Request)
+			token, extractor, err := lookup.ExtractTokenFromGin(c)
 			if err != nil {
+				if o.optional && errors.Is(err, ErrMissingValue) {
+					c.Next()
+					return
+				}
 				o.unauthorizedFallback(c, err)
 				c.Abort()
 				return
 			}
-			c.Request = c.Request.WithContext(NewContext(c.Request.Context(), acc))
+			acc, err := validator.Validate(c.Request.Context(), token)
+			if err != nil {
+				o.unauthorizedFallback(c, err)
+				c.Abort()
+				return
+			}
+			ctx := NewContext(c.Request.Context(), acc)
+			ctx = NewExtractorContext(ctx, extractor)
+			c.Request = c.Request.WithContext(ctx)
+			for _, hook := range o.hooks {
+				if err = hook(c, acc); err != nil {
+					o.unauthorizedFallback(c, err)
+					c.Abort()
+					return
+				}
+			}
+			if renew != nil && needRenew(acc, o.renewWithin) {
+				if token, expiresAt, err := renew.Renew(acc); err == nil {
+					o.renewalWriter(c, token, expiresAt)
+				}
+			}
 		}
 		c.Next()
 	}
 }
+
+// needRenew reports whether the token expires within the duration.
+func needRenew[T any](claims *Claims[T], within time.Duration) bool {
+	return within > 0 &&
+		claims.ExpiresAt != nil &&
+		time.Until(claims.ExpiresAt.Time) < within
+}
@@ -0,0 +1,208 @@
+package authorize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize/revocation/memory"
+)
+
+func testAuthorizeRequest(router http.Handler, path, token string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, path, http.NoBody)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware_BearerError(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+	validToken, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	expiredToken, _, err := newTestAuth(t, Config{Timeout: -time.Hour}).GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	badSignatureToken, _, err := newTestAuth(t, Config{Key: []byte("otherSecretKey")}).GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(auth.Middleware(WithRealm("example")))
+	router.GET("/", func(c *gin.Context) {
+		_, ok := FromContext[*testAccount](c.Request.Context())
+		require.True(t, ok)
+	})
+
+	tests := []struct {
+		name      string
+		token     string
+		code      int
+		challenge string
+		body      string
+	}{
+		{"valid", validToken, http.StatusOK, "", ""},
+		{"missing", "", http.StatusUnauthorized, `Bearer realm="example"`, "token is missing"},
+		{"malformed", "foo", http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="token is malformed"`, "token is malformed"},
+		{"expired", expiredToken, http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="token is expired"`, "token is expired"},
+		{"bad signature", badSignatureToken, http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="token signature is invalid"`, "token signature is invalid"},
+	}
+	for _, tt := range tests {
+		w := testAuthorizeRequest(router, "/", tt.token)
+		require.Equal(t, tt.code, w.Code, tt.name)
+		require.Equal(t, tt.challenge, w.Header().Get("WWW-Authenticate"), tt.name)
+		require.Equal(t, tt.body, w.Body.String(), tt.name)
+	}
+}
+
+// failingStore is a revocation store which is unavailable.
+type failingStore struct{ *memory.Store }
+
+func (failingStore) IsRevoked(context.Context, string) (bool, error) {
+	return false, errors.New("store is unavailable")
+}
+
+func TestMiddleware_StoreFailure(t *testing.T) {
+	auth := newTestAuth(t, Config{RevocationStore: failingStore{memory.NewStore(cache.New(time.Hour, time.Minute))}})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(auth.Middleware(WithProblemDetails(true)))
+	router.GET("/", func(c *gin.Context) {})
+
+	// the failure of the store is not an invalid token.
+	w := testAuthorizeRequest(router, "/", tk)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Empty(t, w.Header().Get("WWW-Authenticate"))
+	var problem map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	require.NotContains(t, problem, "error")
+}
+
+func TestMiddleware_ProblemDetails(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+
+	router := gin.New()
+	router.Use(auth.Middleware(WithProblemDetails(true)))
+	router.GET("/", func(c *gin.Context) {})
+
+	w := testAuthorizeRequest(router, "/", "foo")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem map[string]any
+	err := json.Unmarshal(w.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"type":   "about:blank",
+		"title":  "Unauthorized",
+		"status": float64(http.StatusUnauthorized),
+		"detail": "token is malformed",
+		"error":  "invalid_token",
+	}, problem)
+}
+
+func TestMiddleware_Renewal(t *testing.T) {
+	auth := newTestAuth(t, Config{Timeout: time.Hour, RefreshTimeout: 90 * time.Minute})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	claims, err := auth.ParseToken(tk)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/", auth.Middleware(WithRenewal(10*time.Minute, nil)), func(c *gin.Context) {})
+	router.GET("/renew", auth.Middleware(WithRenewal(2*time.Hour, nil)), func(c *gin.Context) {})
+
+	w := testAuthorizeRequest(router, "/", tk)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("X-Renewed-Token"))
+
+	w = testAuthorizeRequest(router, "/renew", tk)
+	require.Equal(t, http.StatusOK, w.Code)
+	renewedToken := w.Header().Get("X-Renewed-Token")
+	require.NotEmpty(t, renewedToken)
+
+	renewed, err := auth.ParseToken(renewedToken)
+	require.NoError(t, err)
+	require.Equal(t, claims.ID, renewed.ID)
+	require.Equal(t, claims.Subject, renewed.Subject)
+	require.Equal(t, claims.IssuedAt.Unix(), renewed.OrigIssuedAt.Unix())
+	require.LessOrEqual(t, renewed.ExpiresAt.Unix(), claims.IssuedAt.Add(90*time.Minute).Unix())
+}
+
+func TestAuth_Renew(t *testing.T) {
+	auth := newTestAuth(t, Config{Timeout: time.Hour, RefreshTimeout: 90 * time.Minute})
+
+	claims := newTestClaims("1", "alice")
+	claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	_, expiresAt, err := auth.Renew(claims)
+	require.NoError(t, err)
+	require.WithinDuration(t, claims.IssuedAt.Add(90*time.Minute), expiresAt, time.Second)
+
+	// absolute lifetime reached
+	claims.OrigIssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+	_, _, err = auth.Renew(claims)
+	require.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestAuth_RevokeRenewed(t *testing.T) {
+	ctx := context.Background()
+	c := cache.New(time.Hour, time.Minute)
+	auth := newTestAuth(t, Config{
+		Timeout:         time.Minute,
+		RefreshTimeout:  90 * time.Minute,
+		RevocationStore: memory.NewStore(c),
+	})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	claims, err := auth.ParseTokenWithContext(ctx, tk)
+	require.NoError(t, err)
+	renewedToken, _, err := auth.Renew(claims)
+	require.NoError(t, err)
+
+	// the mark outlives the revoked token, until the renewed token expires.
+	require.NoError(t, auth.Revoke(ctx, claims))
+	_, expiresAt, found := c.GetWithExpiration("jti:1")
+	require.True(t, found)
+	require.WithinDuration(t, claims.IssuedAt.Add(90*time.Minute), expiresAt, time.Second)
+	_, err = auth.ParseTokenWithContext(ctx, renewedToken)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestMiddleware_Optional(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(auth.Middleware(WithOptional(true)))
+	router.GET("/", func(c *gin.Context) {
+		if IsAuthenticated(c.Request.Context()) {
+			claims, _ := FromContext[*testAccount](c.Request.Context())
+			c.String(http.StatusOK, claims.Subject)
+		} else {
+			c.String(http.StatusOK, "anonymous")
+		}
+	})
+
+	w := testAuthorizeRequest(router, "/", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "anonymous", w.Body.String())
+
+	w = testAuthorizeRequest(router, "/", tk)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "alice", w.Body.String())
+
+	w = testAuthorizeRequest(router, "/", "foo")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,95 @@
+package authorize
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// ClaimsHook runs after the token is parsed by Middleware, a non-nil error rejects the request
+// with the unauthorized fallback. it can enrich the context by replacing c.Request.
+type ClaimsHook func(c *gin.Context, claims any) error
+
+// WithClaimsHook adds a hook which runs after the token is parsed, hooks run in order.
+// prefer WithClaimsValidator and WithPrincipalLoader, which are type safe.
+func WithClaimsHook(hook ClaimsHook) Option {
+	return func(o *options) {
+		if hook != nil {
+			o.hooks = append(o.hooks, hook)
+		}
+	}
+}
+
+// WithClaimsValidator adds a validator which runs after the token is parsed,
+// a non-nil error rejects the request with the unauthorized fallback, return a BearerError or
+// the error classified by NewBearerError, such as ErrPrincipalDisabled, to reject the token,
+// other errors respond 500.
+// NOTE: T must be the same as the Auth[T] whose Middleware is used.
+func WithClaimsValidator[T any](f func(c *gin.Context, claims *Claims[T]) error) Option {
+	return WithClaimsHook(func(c *gin.Context, claims any) error {
+		val, err := assertClaims[T](claims)
+		if err != nil {
+			return err
+		}
+		return f(c, val)
+	})
+}
+
+// WithPrincipalLoader adds a loader which loads the full principal of the claims, such as the user
+// from the database, and puts it into the context, use PrincipalFromContext to retrieve it.
+// a non-nil error, such as ErrPrincipalDisabled, rejects the request with the unauthorized fallback,
+// the failure of the loader, such as the database error, responds 500.
+// the loaded principal is cached per token id (`jti`) for ttl, if ttl <= 0, it is not cached.
+// NOTE: T must be the same as the Auth[T] whose Middleware is used.
+func WithPrincipalLoader[T, P any](loader func(ctx context.Context, claims *Claims[T]) (P, error), ttl time.Duration) Option {
+	var principals *cache.Cache
+	if ttl > 0 {
+		principals = cache.New(ttl, 2*ttl)
+	}
+	group := new(singleflight.Group)
+	load := func(ctx context.Context, claims *Claims[T]) (P, error) {
+		if principals == nil || claims.ID == "" {
+			return loader(ctx, claims)
+		}
+		if val, found := principals.Get(claims.ID); found {
+			principal, _ := val.(P)
+			return principal, nil
+		}
+		val, err, _ := group.Do(claims.ID, func() (any, error) {
+			// shared by the concurrent requests, so it is not canceled with the first one.
+			principal, err := loader(context.WithoutCancel(ctx), claims)
+			if err != nil {
+				return nil, err
+			}
+			principals.SetDefault(claims.ID, principal)
+			return principal, nil
+		})
+		// the interface typed P may be loaded as nil.
+		principal, _ := val.(P)
+		return principal, err
+	}
+	return WithClaimsHook(func(c *gin.Context, claims any) error {
+		val, err := assertClaims[T](claims)
+		if err != nil {
+			return err
+		}
+		principal, err := load(c.Request.Context(), val)
+		if err != nil {
+			return err
+		}
+		c.Request = c.Request.WithContext(NewPrincipalContext(c.Request.Context(), principal))
+		return nil
+	})
+}
+
+func assertClaims[T any](claims any) (*Claims[T], error) {
+	val, ok := claims.(*Claims[T])
+	if !ok {
+		return nil, fmt.Errorf("authorize: claims type mismatch, want %T, got %T", val, claims)
+	}
+	return val, nil
+}
@@ -0,0 +1,100 @@
+package authorize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	Name     string
+	Disabled bool
+}
+
+func TestMiddleware_PrincipalLoader(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+	users := map[string]*testUser{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob", Disabled: true},
+	}
+	loads := 0
+	loader := func(_ context.Context, claims *Claims[*testAccount]) (*testUser, error) {
+		loads++
+		user, ok := users[claims.Subject]
+		if !ok {
+			return nil, errors.New("user not found")
+		}
+		if user.Disabled {
+			return nil, ErrPrincipalDisabled
+		}
+		return user, nil
+	}
+
+	router := gin.New()
+	router.Use(auth.Middleware(
+		WithClaimsValidator(func(c *gin.Context, claims *Claims[*testAccount]) error {
+			if claims.Meta == nil {
+				return errors.New("missing meta")
+			}
+			return nil
+		}),
+		WithPrincipalLoader(loader, time.Minute),
+	))
+	router.GET("/", func(c *gin.Context) {
+		user, ok := PrincipalFromContext[*testUser](c.Request.Context())
+		require.True(t, ok)
+		c.String(http.StatusOK, user.Name)
+	})
+
+	aliceToken, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	bobToken, _, err := auth.GenerateToken(newTestClaims("2", "bob"))
+	require.NoError(t, err)
+
+	for range 3 {
+		w := testAuthorizeRequest(router, "/", aliceToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "alice", w.Body.String())
+	}
+	require.Equal(t, 1, loads, "principal should be cached per jti")
+
+	w := testAuthorizeRequest(router, "/", bobToken)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, "principal is disabled", w.Body.String())
+
+	// claims type mismatch never passes.
+	router = gin.New()
+	router.Use(auth.Middleware(WithClaimsValidator(func(c *gin.Context, claims *Claims[string]) error {
+		return nil
+	})))
+	router.GET("/", func(c *gin.Context) {})
+	w = testAuthorizeRequest(router, "/", aliceToken)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestMiddleware_PrincipalLoaderNil(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+	loader := func(ctx context.Context, _ *Claims[*testAccount]) (fmt.Stringer, error) {
+		// the load is shared by the concurrent requests, it is not canceled with the request.
+		require.Nil(t, ctx.Done())
+		return nil, nil
+	}
+	router := gin.New()
+	router.Use(auth.Middleware(WithPrincipalLoader(loader, time.Minute)))
+	router.GET("/", func(c *gin.Context) {
+		principal, ok := PrincipalFromContext[fmt.Stringer](c.Request.Context())
+		c.String(http.StatusOK, "%v %v", principal, ok)
+	})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	for range 2 {
+		w := testAuthorizeRequest(router, "/", tk)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+}
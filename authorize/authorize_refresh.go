@@ -0,0 +1,158 @@
+package authorize
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+)
+
+// DefaultRefreshLookup default lookup of RefreshHandler to extract the refresh token.
+const DefaultRefreshLookup = "header:X-Refresh-Token,query:refresh_token"
+
+// TokenPair an access token and a refresh token of the same family.
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// GenerateTokenPair generate an access token and a refresh token.
+// if val.Family is empty, a new family is started, and the refresh token is recorded
+// in the FamilyStore as the current one of the family.
+func (a *Auth[T]) GenerateTokenPair(ctx context.Context, val *Claims[T]) (*TokenPair, error) {
+	if val.Family == "" {
+		val.Family = newId()
+	}
+	if val.ID == "" {
+		val.ID = newId()
+	}
+	return a.generateTokenPair(val, func(refreshId string, expiresAt time.Time) error {
+		if a.families == nil {
+			return nil
+		}
+		return a.families.Issue(ctx, val.Family, refreshId, expiresAt)
+	})
+}
+
+// Refresh exchanges the refresh token for a new token pair of the same family.
+// if FamilyStore is set, refresh tokens are single-use, reuse of an already rotated
+// refresh token revokes the whole family and returns ErrRefreshTokenReused, the refresh token
+// not issued by GenerateTokenPair has no family and is rejected with ErrRefreshTokenNoFamily.
+// the Confirmation of the refresh token is kept, so the new pair is bound to the same key,
+// the caller should verify the binding of the refresh request, such as DPoP.Verify.
+// the issue time of the first token of the family is kept in OrigIssuedAt, the new pair expires
+// no later than OrigIssuedAt + MaxTimeout, returns ErrTokenExpired if it is already reached.
+func (a *Auth[T]) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := a.ParseRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if a.families != nil && claims.Family == "" {
+		return nil, ErrRefreshTokenNoFamily
+	}
+	origIssuedAt := claims.OrigIssuedAt
+	if origIssuedAt == nil {
+		origIssuedAt = claims.IssuedAt
+	}
+	if origIssuedAt == nil {
+		return nil, &ClaimError{Claim: "iat", Err: ErrMissingClaim}
+	}
+	if !time.Now().Before(origIssuedAt.Add(a.refreshTimeout)) {
+		return nil, ErrTokenExpired
+	}
+	oldId := claims.ID
+	val := &Claims[T]{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       newId(),
+			Subject:  claims.Subject,
+			Audience: claims.Audience,
+		},
+		Family:       claims.Family,
+		Scope:        claims.Scope,
+		Roles:        claims.Roles,
+		ClientID:     claims.ClientID,
+		OrigIssuedAt: origIssuedAt,
+		Confirmation: claims.Confirmation,
+		Meta:         claims.Meta,
+	}
+	return a.generateTokenPair(val, func(refreshId string, expiresAt time.Time) error {
+		if a.families == nil {
+			return nil
+		}
+		err := a.families.Rotate(ctx, val.Family, oldId, refreshId, expiresAt)
+		if errors.Is(err, revocation.ErrReused) {
+			err = a.families.RevokeFamily(ctx, val.Family, time.Now().Add(a.refreshTimeout))
+			if err != nil {
+				return err
+			}
+			return ErrRefreshTokenReused
+		}
+		return err
+	})
+}
+
+// RevokeFamily revokes the whole family which the claims belong to, so none of its refresh tokens
+// can be exchanged any more. it is a no-op if FamilyStore is not set or the claims have no family.
+func (a *Auth[T]) RevokeFamily(ctx context.Context, claims *Claims[T]) error {
+	if a.families == nil || claims.Family == "" {
+		return nil
+	}
+	return a.families.RevokeFamily(ctx, claims.Family, time.Now().Add(a.refreshTimeout))
+}
+
+// RefreshHandler returns a handler which exchanges the refresh token for a new token pair,
+// and responds the TokenPair as json.
+// lookup used to extract the refresh token, if it is nil, use DefaultRefreshLookup.
+// the unauthorized fallback of opts is used when the refresh token is missing or invalid.
+func (a *Auth[T]) RefreshHandler(lookup *Lookup, opts ...Option) gin.HandlerFunc {
+	if lookup == nil {
+		lookup = NewLookup(DefaultRefreshLookup)
+	}
+	o := newOptions(opts...)
+	return func(c *gin.Context) {
+		refreshToken, _, err := lookup.ExtractTokenFromGin(c)
+		if err != nil {
+			o.unauthorizedFallback(c, err)
+			c.Abort()
+			return
+		}
+		pair, err := a.Refresh(c.Request.Context(), refreshToken)
+		if err != nil {
+			o.unauthorizedFallback(c, err)
+			c.Abort()
+			return
+		}
+		c.JSON(http.StatusOK, pair)
+	}
+}
+
+// generateTokenPair generate the token pair, issue is called with the id
+// of the new refresh token before it is returned.
+func (a *Auth[T]) generateTokenPair(val *Claims[T], issue func(refreshId string, expiresAt time.Time) error) (*TokenPair, error) {
+	access, refresh := *val, *val
+	refresh.ID = newId()
+	accessToken, accessExpiresAt, err := a.GenerateToken(&access)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshExpiresAt, err := a.GenerateRefreshToken(&refresh)
+	if err != nil {
+		return nil, err
+	}
+	if err = issue(refresh.ID, refreshExpiresAt); err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package authorize
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Require returns a middleware which requires the claims put by Middleware
+// satisfy f, otherwise responds 403 with `WWW-Authenticate: Bearer error="insufficient_scope"`.
+// if there are no claims in the context, responds 401 with `WWW-Authenticate: Bearer`.
+// the response is written by AbortWithBearerError.
+// NOTE: T must be the same as the Auth[T] whose Middleware is used.
+func Require[T any](f func(*Claims[T]) bool) gin.HandlerFunc {
+	return requireClaims(f)
+}
+
+// RequireScopes returns a middleware which requires the token grants all the scopes.
+func RequireScopes[T any](scopes ...string) gin.HandlerFunc {
+	return requireClaims(func(c *Claims[T]) bool { return c.HasScopes(scopes...) }, scopes...)
+}
+
+// RequireAnyScope returns a middleware which requires the token grants any of the scopes.
+func RequireAnyScope[T any](scopes ...string) gin.HandlerFunc {
+	return requireClaims(func(c *Claims[T]) bool { return c.HasAnyScope(scopes...) }, scopes...)
+}
+
+// RequireRoles returns a middleware which requires the subject has all the roles.
+func RequireRoles[T any](roles ...string) gin.HandlerFunc {
+	return requireClaims(func(c *Claims[T]) bool { return c.HasRoles(roles...) })
+}
+
+// RequireAnyRole returns a middleware which requires the subject has any of the roles.
+func RequireAnyRole[T any](roles ...string) gin.HandlerFunc {
+	return requireClaims(func(c *Claims[T]) bool { return c.HasAnyRole(roles...) })
+}
+
+// requireClaims scopes is the scopes hint of the `WWW-Authenticate` header.
+func requireClaims[T any](f func(*Claims[T]) bool, scopes ...string) gin.HandlerFunc {
+	insufficientScope := &BearerError{
+		Status:      http.StatusForbidden,
+		Code:        ErrorCodeInsufficientScope,
+		Description: "insufficient scope",
+		Scope:       strings.Join(scopes, " "),
+		Err:         ErrInsufficientScope,
+	}
+	return func(c *gin.Context) {
+		claims, ok := FromContext[T](c.Request.Context())
+		if !ok || claims == nil {
+			AbortWithBearerError(c, ErrMissingValue, "", false)
+			return
+		}
+		if !f(claims) {
+			AbortWithBearerError(c, insufficientScope, "", false)
+			return
+		}
+		c.Next()
+	}
+}
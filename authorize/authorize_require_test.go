@@ -0,0 +1,56 @@
+package authorize
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequire(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+	claims := newTestClaims("1", "alice")
+	claims.Scope = "orders:read orders:write"
+	claims.Roles = []string{"admin"}
+	tk, _, err := auth.GenerateToken(claims)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/anonymous", RequireScopes[*testAccount]("orders:read"), func(c *gin.Context) {})
+	router.Use(auth.Middleware())
+	router.GET("/scopes", RequireScopes[*testAccount]("orders:read", "orders:write"), func(c *gin.Context) {})
+	router.GET("/scopes/missing", RequireScopes[*testAccount]("orders:read", "users:read"), func(c *gin.Context) {})
+	router.GET("/scopes/any", RequireAnyScope[*testAccount]("users:read", "orders:read"), func(c *gin.Context) {})
+	router.GET("/roles", RequireRoles[*testAccount]("admin"), func(c *gin.Context) {})
+	router.GET("/roles/any", RequireAnyRole[*testAccount]("ops", "admin"), func(c *gin.Context) {})
+	router.GET("/roles/missing", RequireAnyRole[*testAccount]("ops"), func(c *gin.Context) {})
+	router.GET("/require", Require(func(c *Claims[*testAccount]) bool {
+		return c.Meta.Username == "test"
+	}), func(c *gin.Context) {})
+
+	tests := []struct {
+		path      string
+		code      int
+		challenge string
+	}{
+		{"/anonymous", http.StatusUnauthorized, "Bearer"},
+		{"/scopes", http.StatusOK, ""},
+		{"/scopes/missing", http.StatusForbidden, `Bearer error="insufficient_scope", error_description="insufficient scope", scope="orders:read users:read"`},
+		{"/scopes/any", http.StatusOK, ""},
+		{"/roles", http.StatusOK, ""},
+		{"/roles/any", http.StatusOK, ""},
+		{"/roles/missing", http.StatusForbidden, `Bearer error="insufficient_scope", error_description="insufficient scope"`},
+		{"/require", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, tt.path, http.NoBody)
+		r.Header.Set("Authorization", "Bearer "+tk)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		require.Equal(t, tt.code, w.Code, tt.path)
+		require.Equal(t, tt.challenge, w.Header().Get("WWW-Authenticate"), tt.path)
+	}
+}
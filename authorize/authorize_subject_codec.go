@@ -3,6 +3,8 @@ package authorize
 import (
 	"encoding/base64"
 	"encoding/json"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // TokenSubject represents both the subject and connId which is returned
@@ -29,3 +31,69 @@ func Unmarshal(s string, v any) error {
 	}
 	return json.Unmarshal(data, v)
 }
+
+// SubjectCodec encodes the TokenSubject into the `sub` claim, and decodes it.
+type SubjectCodec interface {
+	Encode(ts TokenSubject) (string, error)
+	Decode(sub string) (TokenSubject, error)
+}
+
+// SubjectValidator validates the decoded TokenSubject against the claims.
+type SubjectValidator func(ts TokenSubject, claims *jwt.RegisteredClaims) error
+
+// the built-in subject codecs.
+var (
+	// TokenSubjectCodec encodes the subject and the token id as base64 json of TokenSubject.
+	TokenSubjectCodec SubjectCodec = tokenSubjectCodec{}
+	// PlainSubjectCodec keeps the subject as it is, the token id is only kept in `jti`,
+	// so the token is readable by other jwt consumers, and the token minted elsewhere is accepted.
+	PlainSubjectCodec SubjectCodec = plainSubjectCodec{}
+)
+
+type tokenSubjectCodec struct{}
+
+func (tokenSubjectCodec) Encode(ts TokenSubject) (string, error) { return Marshal(&ts) }
+func (tokenSubjectCodec) Decode(sub string) (TokenSubject, error) {
+	ts := TokenSubject{}
+	err := Unmarshal(sub, &ts)
+	return ts, err
+}
+
+type plainSubjectCodec struct{}
+
+func (plainSubjectCodec) Encode(ts TokenSubject) (string, error)  { return ts.Sub, nil }
+func (plainSubjectCodec) Decode(sub string) (TokenSubject, error) { return TokenSubject{Sub: sub}, nil }
+
+// subjectValidator returns ValidateConnId followed by validator, ValidateConnId is skipped if skipConnId.
+func subjectValidator(skipConnId bool, validator SubjectValidator) SubjectValidator {
+	if skipConnId {
+		return validator
+	}
+	if validator == nil {
+		return ValidateConnId
+	}
+	return func(ts TokenSubject, claims *jwt.RegisteredClaims) error {
+		if err := ValidateConnId(ts, claims); err != nil {
+			return err
+		}
+		return validator(ts, claims)
+	}
+}
+
+// encodesConnId reports whether the connId survives the round trip of the codec.
+func encodesConnId(codec SubjectCodec) bool {
+	sub, err := codec.Encode(TokenSubject{Sub: "sub", ConnId: "connId"})
+	if err != nil {
+		return false
+	}
+	ts, err := codec.Decode(sub)
+	return err == nil && ts.ConnId == "connId"
+}
+
+// ValidateConnId validates the connId of the TokenSubject is equal to the token id (`jti`).
+func ValidateConnId(ts TokenSubject, claims *jwt.RegisteredClaims) error {
+	if ts.ConnId != claims.ID {
+		return jwt.ErrTokenInvalidId
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package authorize
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// newExternalToken returns a token minted by other jwt issuer with the same key.
+func newExternalToken(t *testing.T, id, sub string) string {
+	tk, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ID:        id,
+		Subject:   sub,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte("testSecretKey"))
+	require.NoError(t, err)
+	return tk
+}
+
+func TestSubjectCodec_Plain(t *testing.T) {
+	auth := newTestAuth(t, Config{SubjectCodec: PlainSubjectCodec, SkipConnIdCheck: true})
+
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	registered := &jwt.RegisteredClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(tk, registered)
+	require.NoError(t, err)
+	require.Equal(t, "alice", registered.Subject)
+	require.Equal(t, "1", registered.ID)
+
+	claims, err := auth.ParseToken(tk)
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+
+	claims, err = auth.ParseToken(newExternalToken(t, "2", "bob"))
+	require.NoError(t, err)
+	require.Equal(t, "bob", claims.Subject)
+	require.Equal(t, "2", claims.ID)
+}
+
+func TestSubjectCodec_PlainWithConnIdCheck(t *testing.T) {
+	_, err := New[*testAccount](Config{Key: []byte("testSecretKey"), SubjectCodec: PlainSubjectCodec})
+	require.ErrorIs(t, err, ErrInvalidSubjectCodec)
+}
+
+func TestSubjectCodec_Default(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+	_, err := auth.ParseToken(newExternalToken(t, "2", "bob"))
+	require.ErrorIs(t, err, ErrTokenMalformed)
+
+	sub, err := TokenSubjectCodec.Encode(TokenSubject{Sub: "bob", ConnId: "1"})
+	require.NoError(t, err)
+	tk := newExternalToken(t, "2", sub)
+	_, err = auth.ParseToken(tk)
+	require.ErrorIs(t, err, jwt.ErrTokenInvalidId)
+
+	// the connId check does not depend on whether the SubjectCodec is set.
+	auth = newTestAuth(t, Config{SubjectCodec: TokenSubjectCodec})
+	_, err = auth.ParseToken(tk)
+	require.ErrorIs(t, err, jwt.ErrTokenInvalidId)
+
+	// the connId check is optional.
+	auth = newTestAuth(t, Config{SkipConnIdCheck: true})
+	claims, err := auth.ParseToken(tk)
+	require.NoError(t, err)
+	require.Equal(t, "bob", claims.Subject)
+}
+
+func TestSubjectValidator(t *testing.T) {
+	sub, err := TokenSubjectCodec.Encode(TokenSubject{Sub: "bob", ConnId: "2"})
+	require.NoError(t, err)
+	tk := newExternalToken(t, "2", sub)
+	errBanned := errors.New("banned")
+	validator := func(ts TokenSubject, _ *jwt.RegisteredClaims) error {
+		if ts.Sub == "bob" {
+			return errBanned
+		}
+		return nil
+	}
+
+	_, err = newTestAuth(t, Config{SubjectValidator: validator}).ParseToken(tk)
+	require.ErrorIs(t, err, errBanned)
+	_, err = newTestAuth(t, Config{SubjectValidator: validator}).ParseToken(newExternalToken(t, "1", sub))
+	require.ErrorIs(t, err, jwt.ErrTokenInvalidId)
+}
@@ -0,0 +1,333 @@
+package authorize
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize/revocation/memory"
+)
+
+type testAccount struct {
+	Username string `json:"username,omitempty"`
+}
+
+func newTestAuth(t *testing.T, c Config) *Auth[*testAccount] {
+	if c.Timeout == 0 {
+		c.Timeout = time.Hour
+	}
+	if c.Key == nil {
+		c.Key = []byte("testSecretKey")
+	}
+	auth, err := New[*testAccount](c)
+	require.NoError(t, err)
+	return auth
+}
+
+// newTestRSAKey returns a new rsa private key and public key in PEM.
+func newTestRSAKey(t *testing.T) (privKey, pubKey string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	privKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pubKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+	return privKey, pubKey
+}
+
+func newTestClaims(id, sub string) *Claims[*testAccount] {
+	return &Claims[*testAccount]{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:      id,
+			Subject: sub,
+		},
+		Meta: &testAccount{Username: "test"},
+	}
+}
+
+func TestAuth_GenerateAndParseToken(t *testing.T) {
+	auth := newTestAuth(t, Config{})
+
+	tk, expiresAt, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	require.True(t, expiresAt.After(time.Now()))
+
+	claims, err := auth.ParseToken(tk)
+	require.NoError(t, err)
+	require.Equal(t, "1", claims.ID)
+	require.Equal(t, "alice", claims.Subject)
+	require.Equal(t, "test", claims.Meta.Username)
+}
+
+func TestAuth_Revoke(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("missing store", func(t *testing.T) {
+		auth := newTestAuth(t, Config{})
+		err := auth.Revoke(ctx, newTestClaims("1", "alice"))
+		require.ErrorIs(t, err, ErrMissingRevocationStore)
+		err = auth.RevokeSubject(ctx, "alice", time.Now())
+		require.ErrorIs(t, err, ErrMissingRevocationStore)
+	})
+
+	t.Run("revoke token", func(t *testing.T) {
+		auth := newTestAuth(t, Config{
+			RevocationStore: memory.NewStore(cache.New(time.Hour, time.Minute)),
+		})
+		tk1, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		tk2, _, err := auth.GenerateToken(newTestClaims("2", "alice"))
+		require.NoError(t, err)
+
+		claims, err := auth.ParseTokenWithContext(ctx, tk1)
+		require.NoError(t, err)
+		err = auth.Revoke(ctx, claims)
+		require.NoError(t, err)
+
+		_, err = auth.ParseTokenWithContext(ctx, tk1)
+		require.ErrorIs(t, err, ErrTokenRevoked)
+		_, err = auth.ParseTokenWithContext(ctx, tk2)
+		require.NoError(t, err)
+	})
+
+	t.Run("revoke subject", func(t *testing.T) {
+		auth := newTestAuth(t, Config{
+			RevocationStore: memory.NewStore(cache.New(time.Hour, time.Minute)),
+		})
+		tk1, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		tk2, _, err := auth.GenerateToken(newTestClaims("2", "bob"))
+		require.NoError(t, err)
+
+		err = auth.RevokeSubject(ctx, "alice", time.Now().Add(time.Second))
+		require.NoError(t, err)
+
+		_, err = auth.ParseTokenWithContext(ctx, tk1)
+		require.ErrorIs(t, err, ErrTokenRevoked)
+		_, err = auth.ParseTokenWithContext(ctx, tk2)
+		require.NoError(t, err)
+
+		// tokens issued after the revocation are still valid.
+		err = auth.RevokeSubject(ctx, "bob", time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		_, err = auth.ParseTokenWithContext(ctx, tk2)
+		require.NoError(t, err)
+	})
+
+	t.Run("issued in the same second of revoke subject", func(t *testing.T) {
+		auth := newTestAuth(t, Config{
+			RevocationStore: memory.NewStore(cache.New(time.Hour, time.Minute)),
+		})
+		now := time.Now()
+		err := auth.RevokeSubject(ctx, "alice", now)
+		require.NoError(t, err)
+		tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		// fail closed, the token issued in the same second is revoked.
+		_, err = auth.ParseTokenWithContext(ctx, tk)
+		require.ErrorIs(t, err, ErrTokenRevoked)
+
+		// the token issued in the next second is valid.
+		err = auth.RevokeSubject(ctx, "alice", now.Add(-time.Second))
+		require.NoError(t, err)
+		_, err = auth.ParseTokenWithContext(ctx, tk)
+		require.NoError(t, err)
+	})
+}
+
+func TestAuth_TokenUse(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t, Config{})
+
+	refreshToken, _, err := auth.GenerateRefreshToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	_, err = auth.ParseToken(refreshToken)
+	require.ErrorIs(t, err, ErrTokenUseMismatch)
+	_, err = auth.ParseRefreshToken(ctx, refreshToken)
+	require.NoError(t, err)
+
+	accessToken, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	_, err = auth.ParseRefreshToken(ctx, accessToken)
+	require.ErrorIs(t, err, ErrTokenUseMismatch)
+}
+
+func TestAuth_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stateless", func(t *testing.T) {
+		auth := newTestAuth(t, Config{})
+		pair, err := auth.GenerateTokenPair(ctx, newTestClaims("", "alice"))
+		require.NoError(t, err)
+
+		newPair, err := auth.Refresh(ctx, pair.RefreshToken)
+		require.NoError(t, err)
+		claims, err := auth.ParseToken(newPair.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, "alice", claims.Subject)
+		require.Equal(t, "test", claims.Meta.Username)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		store := memory.NewStore(cache.New(time.Hour, time.Minute))
+		auth := newTestAuth(t, Config{
+			RevocationStore: store,
+			FamilyStore:     store,
+		})
+		pair1, err := auth.GenerateTokenPair(ctx, newTestClaims("", "alice"))
+		require.NoError(t, err)
+
+		pair2, err := auth.Refresh(ctx, pair1.RefreshToken)
+		require.NoError(t, err)
+		require.NotEqual(t, pair1.RefreshToken, pair2.RefreshToken)
+
+		claims1, err := auth.ParseToken(pair1.AccessToken)
+		require.NoError(t, err)
+		claims2, err := auth.ParseToken(pair2.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, claims1.Family, claims2.Family)
+
+		// reuse the rotated refresh token revokes the whole family.
+		_, err = auth.Refresh(ctx, pair1.RefreshToken)
+		require.ErrorIs(t, err, ErrRefreshTokenReused)
+		_, err = auth.Refresh(ctx, pair2.RefreshToken)
+		require.ErrorIs(t, err, ErrTokenRevoked)
+		_, err = auth.ParseToken(pair2.AccessToken)
+		require.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("family deadline", func(t *testing.T) {
+		auth := newTestAuth(t, Config{Timeout: time.Hour, RefreshTimeout: 90 * time.Minute})
+		pair, err := auth.GenerateTokenPair(ctx, newTestClaims("", "alice"))
+		require.NoError(t, err)
+		first, err := auth.ParseRefreshToken(ctx, pair.RefreshToken)
+		require.NoError(t, err)
+
+		// the rotated tokens keep the issue time of the first token.
+		pair, err = auth.Refresh(ctx, pair.RefreshToken)
+		require.NoError(t, err)
+		claims, err := auth.ParseRefreshToken(ctx, pair.RefreshToken)
+		require.NoError(t, err)
+		require.Equal(t, first.IssuedAt.Unix(), claims.OrigIssuedAt.Unix())
+
+		// the family started 80 minutes ago only lives 10 minutes more.
+		val := newTestClaims("1", "alice")
+		val.OrigIssuedAt = jwt.NewNumericDate(time.Now().Add(-80 * time.Minute))
+		refreshToken, _, err := auth.GenerateRefreshToken(val)
+		require.NoError(t, err)
+		pair, err = auth.Refresh(ctx, refreshToken)
+		require.NoError(t, err)
+		require.WithinDuration(t, val.OrigIssuedAt.Add(90*time.Minute), pair.AccessExpiresAt, time.Second)
+		require.WithinDuration(t, val.OrigIssuedAt.Add(90*time.Minute), pair.RefreshExpiresAt, time.Second)
+
+		// the family deadline is reached.
+		val.OrigIssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+		refreshToken, _, err = auth.GenerateRefreshToken(val)
+		require.NoError(t, err)
+		_, err = auth.Refresh(ctx, refreshToken)
+		require.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("refresh token without family", func(t *testing.T) {
+		store := memory.NewStore(cache.New(time.Hour, time.Minute))
+		auth := newTestAuth(t, Config{FamilyStore: store})
+		refreshToken, _, err := auth.GenerateRefreshToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		_, err = auth.Refresh(ctx, refreshToken)
+		require.ErrorIs(t, err, ErrRefreshTokenNoFamily)
+		require.Equal(t, "refresh token has no family", NewBearerError(err).Description)
+	})
+}
+
+func TestNew_RefreshTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		timeout        time.Duration
+		refreshTimeout time.Duration
+		want           time.Duration
+	}{
+		{"default", time.Hour, 0, time.Hour + 30*time.Minute},
+		{"not longer than timeout", time.Hour, time.Hour, time.Hour + 30*time.Minute},
+		{"longer than timeout", time.Hour, 7 * 24 * time.Hour, 7 * 24 * time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := newTestAuth(t, Config{Timeout: tt.timeout, RefreshTimeout: tt.refreshTimeout})
+			require.Equal(t, tt.want, auth.MaxTimeout())
+		})
+	}
+}
+
+func TestAuth_Validation(t *testing.T) {
+	t.Run("unknown required claim", func(t *testing.T) {
+		_, err := New[*testAccount](Config{
+			Key:            []byte("testSecretKey"),
+			RequiredClaims: []string{"foo"},
+		})
+		require.ErrorIs(t, err, ErrUnknownClaim)
+	})
+
+	t.Run("issuer", func(t *testing.T) {
+		issuer := newTestAuth(t, Config{Issuer: "a"})
+		tk, _, err := issuer.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		claims, err := issuer.ParseToken(tk)
+		require.NoError(t, err)
+		require.Equal(t, "a", claims.Issuer)
+
+		_, err = newTestAuth(t, Config{ValidIssuers: []string{"a", "b"}}).ParseToken(tk)
+		require.NoError(t, err)
+		_, err = newTestAuth(t, Config{ValidIssuers: []string{"b"}}).ParseToken(tk)
+		require.ErrorIs(t, err, ErrInvalidIssuer)
+		var claimErr *ClaimError
+		require.ErrorAs(t, err, &claimErr)
+		require.Equal(t, "iss", claimErr.Claim)
+	})
+
+	t.Run("audience", func(t *testing.T) {
+		issuer := newTestAuth(t, Config{Audience: []string{"api", "web"}})
+		tk, _, err := issuer.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+
+		_, err = newTestAuth(t, Config{ValidAudiences: []string{"web"}}).ParseToken(tk)
+		require.NoError(t, err)
+		_, err = newTestAuth(t, Config{ValidAudiences: []string{"admin"}}).ParseToken(tk)
+		require.ErrorIs(t, err, ErrInvalidAudience)
+	})
+
+	t.Run("required claims", func(t *testing.T) {
+		tk, _, err := newTestAuth(t, Config{}).GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+
+		_, err = newTestAuth(t, Config{RequiredClaims: []string{"exp", "iat", "jti"}}).ParseToken(tk)
+		require.NoError(t, err)
+		_, err = newTestAuth(t, Config{RequiredClaims: []string{"aud"}}).ParseToken(tk)
+		require.ErrorIs(t, err, ErrMissingClaim)
+	})
+
+	t.Run("leeway", func(t *testing.T) {
+		auth := newTestAuth(t, Config{})
+		// expired 10 seconds ago.
+		now := time.Now()
+		val := newTestClaims("1", "alice")
+		sub, err := auth.subjectCodec.Encode(TokenSubject{Sub: val.Subject, ConnId: val.ID})
+		require.NoError(t, err)
+		val.Subject = sub
+		val.IssuedAt = jwt.NewNumericDate(now.Add(-time.Minute))
+		val.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second))
+		tk, err := auth.sign(val)
+		require.NoError(t, err)
+		_, err = auth.ParseToken(tk)
+		require.ErrorIs(t, err, jwt.ErrTokenExpired)
+
+		_, err = newTestAuth(t, Config{Leeway: time.Minute}).ParseToken(tk)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package authorize
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// validation the extra validation of the registered claims,
+// `exp`, `nbf` and `iat` are validated by the jwt parser with leeway.
+type validation struct {
+	issuers        []string
+	audiences      []string
+	leeway         time.Duration
+	requiredClaims []string
+}
+
+// check the validation config.
+func (v *validation) check() error {
+	for _, claim := range v.requiredClaims {
+		if _, known := claimPresent(&jwt.RegisteredClaims{}, claim); !known {
+			return fmt.Errorf("%w: %s", ErrUnknownClaim, claim)
+		}
+	}
+	return nil
+}
+
+func (v *validation) validate(claims *jwt.RegisteredClaims) error {
+	for _, claim := range v.requiredClaims {
+		if present, _ := claimPresent(claims, claim); !present {
+			return &ClaimError{Claim: claim, Err: ErrMissingClaim}
+		}
+	}
+	if len(v.issuers) > 0 && !slices.Contains(v.issuers, claims.Issuer) {
+		return &ClaimError{Claim: "iss", Err: ErrInvalidIssuer}
+	}
+	if len(v.audiences) > 0 && !slices.ContainsFunc(v.audiences, func(aud string) bool {
+		return slices.Contains(claims.Audience, aud)
+	}) {
+		return &ClaimError{Claim: "aud", Err: ErrInvalidAudience}
+	}
+	return nil
+}
+
+// claimPresent reports whether the registered claim is present,
+// known is false if it is not a registered claim.
+func claimPresent(claims *jwt.RegisteredClaims, claim string) (present, known bool) {
+	switch claim {
+	case "iss":
+		present = claims.Issuer != ""
+	case "sub":
+		present = claims.Subject != ""
+	case "aud":
+		present = len(claims.Audience) > 0
+	case "exp":
+		present = claims.ExpiresAt != nil
+	case "nbf":
+		present = claims.NotBefore != nil
+	case "iat":
+		present = claims.IssuedAt != nil
+	case "jti":
+		present = claims.ID != ""
+	default:
+		return false, false
+	}
+	return present, true
+}
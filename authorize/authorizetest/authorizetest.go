@@ -0,0 +1,239 @@
+// Package authorizetest provides utilities for testing handlers behind authorize.Auth,
+// such as an in-memory Auth with generated keys, a token factory and a fake middleware.
+package authorizetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/things-go/gin-contrib/authorize"
+)
+
+// the defaults of the Auth created by New.
+const (
+	// Issuer the default issuer.
+	Issuer = "https://authorizetest.local"
+	// KeyID the id of the generated signing key.
+	KeyID = "authorizetest"
+	// Timeout the default token timeout.
+	Timeout = time.Hour
+)
+
+// Env an in-memory Auth with the generated ES256 key, and the token factory signing with the same key.
+type Env[T any] struct {
+	tb     testing.TB
+	config authorize.Config
+	// Auth the Auth which verifies the tokens of the factory.
+	Auth *authorize.Auth[T]
+	// Key the generated signing key.
+	Key *ecdsa.PrivateKey
+}
+
+// New new Env with the generated ES256 key, the Config can be customized by the opts,
+// the default Issuer is also the only valid issuer.
+// NOTE: the keys and Encryption of the Config are ignored.
+func New[T any](tb testing.TB, opts ...func(*authorize.Config)) *Env[T] {
+	tb.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatalf("authorizetest: generate key failure, %v", err)
+	}
+	c := authorize.Config{
+		Timeout:      Timeout,
+		Issuer:       Issuer,
+		ValidIssuers: []string{Issuer},
+	}
+	for _, f := range opts {
+		f(&c)
+	}
+	c.Algorithm = "ES256"
+	c.PrivateKey = key
+	c.PublicKey = nil
+	c.KeyID = KeyID
+	c.Signer = nil
+	c.Encryption = nil
+	auth, err := authorize.New[T](c)
+	if err != nil {
+		tb.Fatalf("authorizetest: new auth failure, %v", err)
+	}
+	return &Env[T]{tb: tb, config: c, Auth: auth, Key: key}
+}
+
+// Token returns a TokenBuilder of the subject, the token is valid by default.
+func (e *Env[T]) Token(subject string) *TokenBuilder[T] {
+	now := time.Now()
+	return &TokenBuilder[T]{
+		env: e,
+		claims: &authorize.Claims[T]{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    e.config.Issuer,
+				Subject:   subject,
+				Audience:  e.config.Audience,
+				ExpiresAt: jwt.NewNumericDate(now.Add(e.config.Timeout)),
+				NotBefore: jwt.NewNumericDate(now),
+				IssuedAt:  jwt.NewNumericDate(now),
+				ID:        ulid.Make().String(),
+			},
+			TokenUse: authorize.TokenUseAccess,
+		},
+		key: e.Key,
+	}
+}
+
+// TokenBuilder builds the token with the builder methods, the claims are not checked.
+type TokenBuilder[T any] struct {
+	env      *Env[T]
+	claims   *authorize.Claims[T]
+	key      *ecdsa.PrivateKey
+	tampered bool
+}
+
+// ID sets the token id (`jti`).
+func (b *TokenBuilder[T]) ID(id string) *TokenBuilder[T] {
+	b.claims.ID = id
+	return b
+}
+
+// Meta sets the meta of the claims.
+func (b *TokenBuilder[T]) Meta(meta T) *TokenBuilder[T] {
+	b.claims.Meta = meta
+	return b
+}
+
+// Scope sets the scopes.
+func (b *TokenBuilder[T]) Scope(scopes ...string) *TokenBuilder[T] {
+	b.claims.Scope = strings.Join(scopes, " ")
+	return b
+}
+
+// Roles sets the roles.
+func (b *TokenBuilder[T]) Roles(roles ...string) *TokenBuilder[T] {
+	b.claims.Roles = roles
+	return b
+}
+
+// Audience sets the audience.
+func (b *TokenBuilder[T]) Audience(aud ...string) *TokenBuilder[T] {
+	b.claims.Audience = aud
+	return b
+}
+
+// Issuer sets the issuer.
+func (b *TokenBuilder[T]) Issuer(iss string) *TokenBuilder[T] {
+	b.claims.Issuer = iss
+	return b
+}
+
+// WrongIssuer sets an issuer which is not valid.
+func (b *TokenBuilder[T]) WrongIssuer() *TokenBuilder[T] {
+	return b.Issuer("https://wrong-issuer.authorizetest.local")
+}
+
+// Expired makes the token expired a minute ago.
+func (b *TokenBuilder[T]) Expired() *TokenBuilder[T] {
+	now := time.Now()
+	b.claims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+	b.claims.NotBefore = jwt.NewNumericDate(now.Add(-time.Hour))
+	b.claims.IssuedAt = jwt.NewNumericDate(now.Add(-time.Hour))
+	return b
+}
+
+// NotYetValid makes the token valid after a minute.
+func (b *TokenBuilder[T]) NotYetValid() *TokenBuilder[T] {
+	b.claims.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	return b
+}
+
+// Refresh makes the token a refresh token.
+func (b *TokenBuilder[T]) Refresh() *TokenBuilder[T] {
+	b.claims.TokenUse = authorize.TokenUseRefresh
+	return b
+}
+
+// Tampered flips a bit of the signature, so the signature is invalid.
+func (b *TokenBuilder[T]) Tampered() *TokenBuilder[T] {
+	b.tampered = true
+	return b
+}
+
+// UnknownKey signs the token with another generated key.
+func (b *TokenBuilder[T]) UnknownKey() *TokenBuilder[T] {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		b.env.tb.Fatalf("authorizetest: generate key failure, %v", err)
+	}
+	b.key = key
+	return b
+}
+
+// With modifies the claims directly.
+func (b *TokenBuilder[T]) With(f func(*authorize.Claims[T])) *TokenBuilder[T] {
+	f(b.claims)
+	return b
+}
+
+// Claims returns the claims of the token, the subject is not encoded.
+func (b *TokenBuilder[T]) Claims() *authorize.Claims[T] { return b.claims }
+
+// Sign signs the token, it fails the test if any error.
+func (b *TokenBuilder[T]) Sign() string {
+	b.env.tb.Helper()
+	codec := b.env.config.SubjectCodec
+	if codec == nil {
+		codec = authorize.TokenSubjectCodec
+	}
+	val := *b.claims
+	sub, err := codec.Encode(authorize.TokenSubject{Sub: val.Subject, ConnId: val.ID})
+	if err != nil {
+		b.env.tb.Fatalf("authorizetest: encode subject failure, %v", err)
+	}
+	val.Subject = sub
+	tk := jwt.NewWithClaims(jwt.SigningMethodES256, &val)
+	tk.Header["kid"] = KeyID
+	token, err := tk.SignedString(b.key)
+	if err != nil {
+		b.env.tb.Fatalf("authorizetest: sign token failure, %v", err)
+	}
+	if b.tampered {
+		token = tamper(token)
+	}
+	return token
+}
+
+// tamper flips the first bit of the signature.
+func tamper(token string) string {
+	i := strings.LastIndexByte(token, '.')
+	sig, _ := base64.RawURLEncoding.DecodeString(token[i+1:])
+	sig[0] ^= 0x01
+	return token[:i+1] + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// Middleware returns a fake middleware which injects the claims into the context with authorize.NewContext,
+// the token is not required. if claims is nil, the request is aborted as no token is present.
+func Middleware[T any](claims *authorize.Claims[T]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims == nil {
+			authorize.AbortWithBearerError(c, authorize.ErrMissingValue, "", false)
+			return
+		}
+		c.Request = c.Request.WithContext(authorize.NewContext(c.Request.Context(), claims))
+		c.Next()
+	}
+}
+
+// Reject returns a fake middleware which aborts the request with the error as the Auth middleware does,
+// such as authorize.ErrTokenExpired.
+func Reject(err error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize.AbortWithBearerError(c, err, "", false)
+	}
+}
@@ -0,0 +1,88 @@
+package authorizetest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize"
+)
+
+type account struct {
+	Username string `json:"username"`
+}
+
+func TestTokenBuilder(t *testing.T) {
+	env := New[*account](t)
+
+	claims, err := env.Auth.ParseToken(env.Token("alice").Meta(&account{Username: "alice"}).Scope("read", "write").Sign())
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+	require.Equal(t, "alice", claims.Meta.Username)
+	require.True(t, claims.HasScopes("read", "write"))
+
+	for _, tt := range []struct {
+		name    string
+		builder *TokenBuilder[*account]
+		wantErr error
+	}{
+		{"expired", env.Token("alice").Expired(), authorize.ErrTokenExpired},
+		{"not yet valid", env.Token("alice").NotYetValid(), authorize.ErrTokenNotValidYet},
+		{"wrong issuer", env.Token("alice").WrongIssuer(), authorize.ErrInvalidIssuer},
+		{"tampered", env.Token("alice").Tampered(), authorize.ErrTokenSignatureInvalid},
+		{"unknown key", env.Token("alice").UnknownKey(), authorize.ErrTokenSignatureInvalid},
+		{"refresh", env.Token("alice").Refresh(), authorize.ErrTokenUseMismatch},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.Auth.ParseToken(tt.builder.Sign())
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	env := New[*account](t, func(c *authorize.Config) {
+		c.SubjectCodec = authorize.PlainSubjectCodec
+		c.SkipConnIdCheck = true
+		c.ValidAudiences = []string{"api"}
+	})
+
+	_, err := env.Auth.ParseToken(env.Token("alice").Sign())
+	require.ErrorIs(t, err, authorize.ErrInvalidAudience)
+	claims, err := env.Auth.ParseToken(env.Token("alice").Audience("api").Sign())
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+}
+
+func TestMiddleware(t *testing.T) {
+	serve := func(middleware gin.HandlerFunc) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(middleware)
+		router.GET("/", func(c *gin.Context) {
+			claims, ok := authorize.FromContext[*account](c.Request.Context())
+			require.True(t, ok)
+			c.String(http.StatusOK, claims.Subject)
+		})
+		r, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/", http.NoBody)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	env := New[*account](t)
+	w := serve(Middleware(env.Token("alice").Claims()))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "alice", w.Body.String())
+
+	w = serve(Middleware[*account](nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+	w = serve(Reject(authorize.ErrTokenExpired))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+}
@@ -0,0 +1,183 @@
+package authorize
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RFC 6750 error codes.
+const (
+	// ErrorCodeInvalidRequest the request is missing a required parameter or is otherwise malformed.
+	ErrorCodeInvalidRequest = "invalid_request"
+	// ErrorCodeInvalidToken the access token is expired, revoked, malformed, or invalid for other reasons.
+	ErrorCodeInvalidToken = "invalid_token"
+	// ErrorCodeInsufficientScope the request requires higher privileges than provided by the access token.
+	ErrorCodeInsufficientScope = "insufficient_scope"
+	// ErrorCodeInvalidDPoPProof the DPoP proof is missing or invalid, see RFC 9449.
+	ErrorCodeInvalidDPoPProof = "invalid_dpop_proof"
+)
+
+// ProblemContentType the content type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// BearerError is a RFC 6750 error of bearer token authentication.
+type BearerError struct {
+	// Status http status code.
+	Status int
+	// Code RFC 6750 error code, empty if the request lacks any authentication information.
+	Code string
+	// Description human-readable description, which is safe to expose to the client.
+	Description string
+	// Scope the scopes necessary to access the resource, only used with ErrorCodeInsufficientScope.
+	Scope string
+	// Err the underlying error, which is never exposed to the client.
+	Err error
+}
+
+func (e *BearerError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Description
+}
+
+func (e *BearerError) Unwrap() error { return e.Err }
+
+// Challenge returns the value of the `WWW-Authenticate` header.
+func (e *BearerError) Challenge(realm string) string {
+	params := make([]string, 0, 4)
+	if realm != "" {
+		params = append(params, `realm="`+quoteEscape(realm)+`"`)
+	}
+	if e.Code != "" {
+		params = append(params, `error="`+e.Code+`"`)
+		if e.Description != "" {
+			params = append(params, `error_description="`+quoteEscape(e.Description)+`"`)
+		}
+	}
+	if e.Scope != "" {
+		params = append(params, `scope="`+quoteEscape(e.Scope)+`"`)
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// Problem returns the RFC 7807 problem details.
+func (e *BearerError) Problem() gin.H {
+	problem := gin.H{
+		"type":   "about:blank",
+		"title":  http.StatusText(e.Status),
+		"status": e.Status,
+		"detail": e.Description,
+	}
+	if e.Code != "" {
+		problem["error"] = e.Code
+	}
+	return problem
+}
+
+// NewBearerError classifies the error of authentication into a BearerError.
+// the error returned by ParseToken, ParseFromRequest and Middleware can be classified.
+// the error which is not an authentication error, such as the failure of the revocation store,
+// the introspection endpoint or the principal loader, is classified as 500 without error code.
+func NewBearerError(err error) *BearerError {
+	var bearerErr *BearerError
+	if errors.As(err, &bearerErr) {
+		return bearerErr
+	}
+	e := &BearerError{
+		Status:      http.StatusUnauthorized,
+		Code:        ErrorCodeInvalidToken,
+		Description: "token is invalid",
+		Err:         err,
+	}
+	switch {
+	case errors.Is(err, ErrMissingValue):
+		e.Code, e.Description = "", "token is missing"
+	case errors.Is(err, ErrInsufficientScope):
+		e.Status, e.Code, e.Description = http.StatusForbidden, ErrorCodeInsufficientScope, "insufficient scope"
+	case errors.Is(err, ErrTokenRevoked):
+		e.Description = "token has been revoked"
+	case errors.Is(err, ErrRefreshTokenReused):
+		e.Description = "refresh token has been reused"
+	case errors.Is(err, ErrRefreshTokenNoFamily):
+		e.Description = "refresh token has no family"
+	case errors.Is(err, ErrPrincipalDisabled):
+		e.Description = "principal is disabled"
+	case errors.Is(err, ErrInvalidDPoPProof):
+		e.Code, e.Description = ErrorCodeInvalidDPoPProof, "dpop proof is invalid"
+	case errors.Is(err, ErrTokenBindingMismatch):
+		e.Description = "token binding mismatch"
+	case errors.Is(err, ErrUnknownTenant):
+		e.Description = "token has unknown tenant"
+	case errors.Is(err, ErrTokenUseMismatch):
+		e.Description = "token use mismatch"
+	case errors.Is(err, ErrTokenExpired):
+		e.Description = "token is expired"
+	case errors.Is(err, ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		e.Description = "token is not valid yet"
+	case errors.Is(err, ErrTokenSignatureInvalid):
+		e.Description = "token signature is invalid"
+	case errors.Is(err, ErrTokenDecryption):
+		e.Description = "token can not be decrypted"
+	case errors.Is(err, ErrTokenMalformed):
+		e.Description = "token is malformed"
+	case errors.Is(err, ErrInvalidIssuer):
+		e.Description = "token has invalid issuer"
+	case errors.Is(err, ErrInvalidAudience):
+		e.Description = "token has invalid audience"
+	case errors.Is(err, ErrMissingClaim):
+		e.Description = "token is missing required claim"
+	case errors.Is(err, ErrTokenInactive):
+		e.Description = "token is not active"
+	case !isTokenError(err):
+		e.Status, e.Code, e.Description = http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError)
+	}
+	return e
+}
+
+// isTokenError reports whether the error is caused by the invalid token.
+func isTokenError(err error) bool {
+	for _, target := range []error{
+		jwt.ErrTokenUnverifiable,
+		jwt.ErrTokenRequiredClaimMissing,
+		jwt.ErrTokenInvalidSubject,
+		jwt.ErrTokenInvalidId,
+		jwt.ErrTokenInvalidClaims,
+		jwt.ErrInvalidType,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// AbortWithBearerError aborts the request with the RFC 6750 error classified by NewBearerError.
+// it sets the `WWW-Authenticate` header, responds RFC 7807 problem details json body if problem is true,
+// otherwise responds the description as plain text.
+func AbortWithBearerError(c *gin.Context, err error, realm string, problem bool) {
+	e := NewBearerError(err)
+	if e.Status < http.StatusInternalServerError {
+		c.Header("WWW-Authenticate", e.Challenge(realm))
+	} else {
+		_ = c.Error(err)
+	}
+	if problem {
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(e.Status, e.Problem())
+	} else {
+		c.String(e.Status, e.Description)
+	}
+	c.Abort()
+}
+
+func quoteEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,324 @@
+package authorize
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+	"github.com/things-go/gin-contrib/authorize/revocation/memory"
+)
+
+// Confirmation the `cnf` claim (RFC 7800) which binds the token to the key of the sender.
+type Confirmation struct {
+	// JKT the JWK SHA-256 thumbprint of the DPoP proof key, see RFC 9449.
+	JKT string `json:"jkt,omitempty"`
+	// X5TS256 the SHA-256 thumbprint of the client certificate, see RFC 8705.
+	X5TS256 string `json:"x5t#S256,omitempty"`
+}
+
+// DPoPConfig the config of DPoP.
+type DPoPConfig struct {
+	// ReplayStore used to reject the replayed proof by its `jti`.
+	// Optional, Default in-memory store, which is not shared across instances.
+	ReplayStore revocation.ReplayStore
+	// MaxAge the max difference between the `iat` of the proof and now.
+	// Optional, Default 1 minute.
+	MaxAge time.Duration
+	// Algorithms the allowed algorithms of the proof.
+	// Optional, Default ES256, ES384, ES512, RS256, RS384, RS512, PS256, PS384, PS512, EdDSA.
+	Algorithms []string
+	// Required rejects the token which is not bound to a DPoP key.
+	// Optional, Default false, the unbound token is accepted as bearer token.
+	Required bool
+	// URL returns the url of the request which is compared to the `htu` of the proof,
+	// set it if the server is behind a reverse proxy.
+	// Optional, Default scheme (https if r.TLS is set) + r.Host + r.URL.Path.
+	URL func(r *http.Request) string
+}
+
+// DPoP verifies the DPoP proof (RFC 9449).
+type DPoP struct {
+	replay     revocation.ReplayStore
+	maxAge     time.Duration
+	algorithms []string
+	required   bool
+	url        func(r *http.Request) string
+}
+
+// dpopClaims the claims of the DPoP proof.
+type dpopClaims struct {
+	jwt.RegisteredClaims
+	Htm string `json:"htm"`
+	Htu string `json:"htu"`
+	Ath string `json:"ath,omitempty"`
+}
+
+// NewDPoP new DPoP with DPoPConfig.
+func NewDPoP(c DPoPConfig) *DPoP {
+	d := &DPoP{
+		replay:     c.ReplayStore,
+		maxAge:     c.MaxAge,
+		algorithms: c.Algorithms,
+		required:   c.Required,
+		url:        c.URL,
+	}
+	if d.replay == nil {
+		d.replay = memory.NewStore(cache.New(cache.NoExpiration, time.Minute))
+	}
+	if d.maxAge <= 0 {
+		d.maxAge = time.Minute
+	}
+	if len(d.algorithms) == 0 {
+		d.algorithms = []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "EdDSA"}
+	}
+	if d.url == nil {
+		d.url = requestURL
+	}
+	return d
+}
+
+// Verify verifies the DPoP proof of the request, returns the JWK thumbprint of the proof key,
+// which is used as Confirmation.JKT when issuing the token.
+// accessToken is the token presented with the proof, it is compared to the `ath` of the proof,
+// if it is empty, such as at the token endpoint, `ath` is not checked.
+func (d *DPoP) Verify(r *http.Request, accessToken string) (string, error) {
+	values := r.Header.Values("DPoP")
+	if len(values) != 1 {
+		return "", fmt.Errorf("%w: exactly one proof is required", ErrInvalidDPoPProof)
+	}
+	var jkt string
+
+	claims := &dpopClaims{}
+	_, err := jwt.ParseWithClaims(values[0], claims, func(t *jwt.Token) (any, error) {
+		if typ, _ := t.Header["typ"].(string); typ != "dpop+jwt" {
+			return nil, fmt.Errorf("unexpected typ %q", typ)
+		}
+		jwk, ok := t.Header["jwk"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("missing jwk")
+		}
+		if _, ok = jwk["d"]; ok {
+			return nil, fmt.Errorf("jwk contains private key")
+		}
+		data, err := json.Marshal(jwk)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePublicKeyJWK(data)
+		if err != nil {
+			return nil, err
+		}
+		if err = checkVerifyingKey(t.Method, key); err != nil {
+			return nil, err
+		}
+		jkt, err = JWKThumbprint(key)
+		return key, err
+	}, jwt.WithValidMethods(d.algorithms))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidDPoPProof, err)
+	}
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return "", fmt.Errorf("%w: missing jti or iat", ErrInvalidDPoPProof)
+	}
+	if age := time.Since(claims.IssuedAt.Time); age > d.maxAge || age < -d.maxAge {
+		return "", fmt.Errorf("%w: iat is out of range", ErrInvalidDPoPProof)
+	}
+	if claims.Htm != r.Method {
+		return "", fmt.Errorf("%w: htm mismatch", ErrInvalidDPoPProof)
+	}
+	if !equalURL(claims.Htu, d.url(r)) {
+		return "", fmt.Errorf("%w: htu mismatch", ErrInvalidDPoPProof)
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		if subtle.ConstantTimeCompare([]byte(claims.Ath), []byte(base64.RawURLEncoding.EncodeToString(sum[:]))) != 1 {
+			return "", fmt.Errorf("%w: ath mismatch", ErrInvalidDPoPProof)
+		}
+	}
+	first, err := d.replay.Use(r.Context(), "dpop:"+jkt+":"+claims.ID, claims.IssuedAt.Add(d.maxAge))
+	if err != nil {
+		return "", err
+	}
+	if !first {
+		return "", fmt.Errorf("%w: proof is replayed", ErrInvalidDPoPProof)
+	}
+	return jkt, nil
+}
+
+// WithDPoP adds a hook which requires the DPoP proof for the token bound by Confirmation.JKT.
+// the token should be extracted with the "DPoP" prefix, such as "header:Authorization:DPoP",
+// the bound token extracted by the other one, such as the "Bearer" prefix, is rejected (RFC 9449 section 7.1).
+func WithDPoP(d *DPoP) Option {
+	return WithClaimsHook(func(c *gin.Context, claims any) error {
+		cnf := confirmationOf(claims)
+		if cnf == nil || cnf.JKT == "" {
+			if d.required {
+				return fmt.Errorf("%w: token is not bound to a DPoP key", ErrTokenBindingMismatch)
+			}
+			return nil
+		}
+		extractor, ok := ExtractorFromContext(c.Request.Context())
+		if !ok {
+			return ErrMissingValue
+		}
+		if header, ok := extractor.(HeaderExtractor); !ok || !strings.EqualFold(header.Prefix, "DPoP") {
+			return fmt.Errorf("%w: bound token requires the DPoP scheme", ErrTokenBindingMismatch)
+		}
+		token, err := extractor.ExtractToken(c.Request)
+		if err != nil {
+			return err
+		}
+		jkt, err := d.Verify(c.Request, token)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(jkt), []byte(cnf.JKT)) != 1 {
+			return fmt.Errorf("%w: jkt mismatch", ErrTokenBindingMismatch)
+		}
+		return nil
+	})
+}
+
+// MTLSConfig the config of WithMTLSBinding.
+type MTLSConfig struct {
+	// Certificate returns the client certificate of the request, see CertificateFromHeader.
+	// Optional, Default the first peer certificate of r.TLS.
+	Certificate func(r *http.Request) (*x509.Certificate, error)
+	// Required rejects the token which is not bound to a certificate.
+	// Optional, Default false.
+	Required bool
+}
+
+// WithMTLSBinding adds a hook which requires the client certificate for the token bound by Confirmation.X5TS256,
+// see RFC 8705.
+func WithMTLSBinding(c MTLSConfig) Option {
+	certificate := c.Certificate
+	if certificate == nil {
+		certificate = peerCertificate
+	}
+	return WithClaimsHook(func(ctx *gin.Context, claims any) error {
+		cnf := confirmationOf(claims)
+		if cnf == nil || cnf.X5TS256 == "" {
+			if c.Required {
+				return fmt.Errorf("%w: token is not bound to a certificate", ErrTokenBindingMismatch)
+			}
+			return nil
+		}
+		cert, err := certificate(ctx.Request)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrTokenBindingMismatch, err)
+		}
+		if subtle.ConstantTimeCompare([]byte(CertificateThumbprint(cert)), []byte(cnf.X5TS256)) != 1 {
+			return fmt.Errorf("%w: certificate thumbprint mismatch", ErrTokenBindingMismatch)
+		}
+		return nil
+	})
+}
+
+// CertificateFromHeader returns a func which gets the client certificate from the header set by the
+// TLS terminating reverse proxy, the value is the url escaped PEM, such as `$ssl_client_escaped_cert` of nginx.
+// NOTE: the header must be set by the trusted reverse proxy only.
+func CertificateFromHeader(name string) func(r *http.Request) (*x509.Certificate, error) {
+	return func(r *http.Request) (*x509.Certificate, error) {
+		value := r.Header.Get(name)
+		if value == "" {
+			return nil, fmt.Errorf("missing client certificate")
+		}
+		data, err := url.QueryUnescape(value)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode([]byte(data))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("invalid client certificate")
+		}
+		return x509.ParseCertificate(block.Bytes)
+	}
+}
+
+// CertificateThumbprint returns the SHA-256 thumbprint of the certificate, which is used as Confirmation.X5TS256.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKThumbprint returns the JWK SHA-256 thumbprint (RFC 7638) of the public key,
+// which is used as Confirmation.JKT.
+func JWKThumbprint(key crypto.PublicKey) (string, error) {
+	enc := base64.RawURLEncoding.EncodeToString
+	var members string
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, enc(big.NewInt(int64(k.E)).Bytes()), enc(k.N.Bytes()))
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`,
+			k.Curve.Params().Name, enc(k.X.FillBytes(make([]byte, size))), enc(k.Y.FillBytes(make([]byte, size))))
+	case *secp256k1.PublicKey:
+		point := k.SerializeUncompressed()
+		members = fmt.Sprintf(`{"crv":"secp256k1","kty":"EC","x":"%s","y":"%s"}`, enc(point[1:33]), enc(point[33:]))
+	case ed25519.PublicKey:
+		members = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, enc(k))
+	default:
+		return "", fmt.Errorf("%w: unsupported key type %T", ErrInvalidPubKey, key)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return enc(sum[:]), nil
+}
+
+func confirmationOf(claims any) *Confirmation {
+	if c, ok := claims.(interface{ confirmation() *Confirmation }); ok {
+		return c.confirmation()
+	}
+	return nil
+}
+
+func peerCertificate(r *http.Request) (*x509.Certificate, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("missing client certificate")
+	}
+	return r.TLS.PeerCertificates[0], nil
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// equalURL reports whether the urls are equal, ignoring the query and fragment,
+// the scheme and host are case-insensitive.
+func equalURL(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) &&
+		strings.EqualFold(ua.Host, ub.Host) &&
+		ua.EscapedPath() == ub.EscapedPath()
+}
@@ -0,0 +1,229 @@
+package authorize
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1
+	key, err := ParsePublicKeyJWK([]byte(`{
+		"kty": "RSA",
+		"n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e": "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29"
+	}`))
+	require.NoError(t, err)
+	jkt, err := JWKThumbprint(key)
+	require.NoError(t, err)
+	require.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jkt)
+}
+
+type testDPoPProof struct {
+	key    *ecdsa.PrivateKey
+	method string
+	htu    string
+	token  string
+	jti    string
+	iat    time.Time
+}
+
+func (p testDPoPProof) sign(t *testing.T) string {
+	size := 32
+	tk := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"jti": p.jti,
+		"htm": p.method,
+		"htu": p.htu,
+		"iat": p.iat.Unix(),
+		"ath": func() string {
+			sum := sha256.Sum256([]byte(p.token))
+			return base64.RawURLEncoding.EncodeToString(sum[:])
+		}(),
+	})
+	tk.Header["typ"] = "dpop+jwt"
+	tk.Header["jwk"] = map[string]any{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(p.key.X.FillBytes(make([]byte, size))),
+		"y":   base64.RawURLEncoding.EncodeToString(p.key.Y.FillBytes(make([]byte, size))),
+	}
+	proof, err := tk.SignedString(p.key)
+	require.NoError(t, err)
+	return proof
+}
+
+func TestWithDPoP(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jkt, err := JWKThumbprint(&key.PublicKey)
+	require.NoError(t, err)
+
+	auth := newTestAuth(t, Config{Lookup: "header:Authorization:DPoP,header:Authorization:Bearer"})
+	bound := newTestClaims("1", "alice")
+	bound.Confirmation = &Confirmation{JKT: jkt}
+	token, _, err := auth.GenerateToken(bound)
+	require.NoError(t, err)
+	bearerToken, _, err := auth.GenerateToken(newTestClaims("2", "bob"))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/resource", auth.Middleware(WithDPoP(NewDPoP(DPoPConfig{}))), func(c *gin.Context) {})
+	router.GET("/required", auth.Middleware(WithDPoP(NewDPoP(DPoPConfig{Required: true}))), func(c *gin.Context) {})
+	request := func(path, scheme, token, proof string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com"+path+"?q=1", nil)
+		req.Header.Set("Authorization", scheme+" "+token)
+		if proof != "" {
+			req.Header.Set("DPoP", proof)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	valid := testDPoPProof{
+		key:    key,
+		method: http.MethodGet,
+		htu:    "http://api.example.com/resource",
+		token:  token,
+		jti:    "1",
+		iat:    time.Now(),
+	}
+
+	w := request("/resource", "DPoP", token, valid.sign(t))
+	require.Equal(t, http.StatusOK, w.Code)
+	// replayed
+	w = request("/resource", "DPoP", token, valid.sign(t))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Header().Get("WWW-Authenticate"), ErrorCodeInvalidDPoPProof)
+
+	for name, proof := range map[string]testDPoPProof{
+		"other key": {otherKey, http.MethodGet, valid.htu, token, "2", time.Now()},
+		"htm":       {key, http.MethodPost, valid.htu, token, "3", time.Now()},
+		"htu":       {key, http.MethodGet, "http://api.example.com/other", token, "4", time.Now()},
+		"ath":       {key, http.MethodGet, valid.htu, bearerToken, "5", time.Now()},
+		"iat":       {key, http.MethodGet, valid.htu, token, "6", time.Now().Add(-time.Hour)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := request("/resource", "DPoP", token, proof.sign(t))
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+	// missing proof
+	w = request("/resource", "DPoP", token, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	// bound token with the Bearer scheme, even with a valid proof.
+	valid.jti = "7"
+	w = request("/resource", "Bearer", token, valid.sign(t))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Header().Get("WWW-Authenticate"), "token binding mismatch")
+
+	// unbound token
+	w = request("/resource", "Bearer", bearerToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = request("/required", "Bearer", bearerToken, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestWithDPoP_Refresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jkt, err := JWKThumbprint(&key.PublicKey)
+	require.NoError(t, err)
+	auth := newTestAuth(t, Config{Lookup: "header:Authorization:DPoP"})
+	bound := newTestClaims("1", "alice")
+	bound.Confirmation = &Confirmation{JKT: jkt}
+	pair, err := auth.GenerateTokenPair(context.Background(), bound)
+	require.NoError(t, err)
+
+	// the rotated pair is still bound to the key.
+	pair, err = auth.Refresh(context.Background(), pair.RefreshToken)
+	require.NoError(t, err)
+	claims, err := auth.ParseRefreshToken(context.Background(), pair.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, jkt, claims.Confirmation.JKT)
+
+	router := gin.New()
+	router.GET("/resource", auth.Middleware(WithDPoP(NewDPoP(DPoPConfig{}))), func(c *gin.Context) {})
+	request := func(proof string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/resource", nil)
+		req.Header.Set("Authorization", "DPoP "+pair.AccessToken)
+		if proof != "" {
+			req.Header.Set("DPoP", proof)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	require.Equal(t, http.StatusUnauthorized, request(""))
+	proof := testDPoPProof{key, http.MethodGet, "http://api.example.com/resource", pair.AccessToken, "1", time.Now()}
+	require.Equal(t, http.StatusOK, request(proof.sign(t)))
+}
+
+func newTestCertificate(t *testing.T, cn string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestWithMTLSBinding(t *testing.T) {
+	cert := newTestCertificate(t, "client")
+	otherCert := newTestCertificate(t, "other")
+
+	auth := newTestAuth(t, Config{})
+	bound := newTestClaims("1", "alice")
+	bound.Confirmation = &Confirmation{X5TS256: CertificateThumbprint(cert)}
+	token, _, err := auth.GenerateToken(bound)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/tls", auth.Middleware(WithMTLSBinding(MTLSConfig{})), func(c *gin.Context) {})
+	router.GET("/proxy", auth.Middleware(WithMTLSBinding(MTLSConfig{Certificate: CertificateFromHeader("X-Client-Cert")})), func(c *gin.Context) {})
+
+	request := func(path string, cert *x509.Certificate) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if cert != nil {
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			req.Header.Set("X-Client-Cert", url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, request("/tls", cert))
+	require.Equal(t, http.StatusUnauthorized, request("/tls", otherCert))
+	require.Equal(t, http.StatusUnauthorized, request("/tls", nil))
+	require.Equal(t, http.StatusOK, request("/proxy", cert))
+	require.Equal(t, http.StatusUnauthorized, request("/proxy", otherCert))
+	require.Equal(t, http.StatusUnauthorized, request("/proxy", nil))
+}
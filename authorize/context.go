@@ -16,3 +16,55 @@ func FromContext[T any](ctx context.Context) (claims *Claims[T], ok bool) {
 	claims, ok = ctx.Value(ctxAuthKey{}).(*Claims[T])
 	return
 }
+
+// IsAuthenticated reports whether the request was authenticated, that is
+// the claims have been put into the context by Middleware.
+func IsAuthenticated(ctx context.Context) bool {
+	return ctx.Value(ctxAuthKey{}) != nil
+}
+
+type ctxExtractorKey struct{}
+
+// NewExtractorContext put the Extractor which the token comes from into context
+func NewExtractorContext(ctx context.Context, extractor Extractor) context.Context {
+	return context.WithValue(ctx, ctxExtractorKey{}, extractor)
+}
+
+// ExtractorFromContext extract the Extractor which the token comes from from context
+func ExtractorFromContext(ctx context.Context) (extractor Extractor, ok bool) {
+	extractor, ok = ctx.Value(ctxExtractorKey{}).(Extractor)
+	return
+}
+
+// IsFromCookie reports whether the token comes from a cookie.
+func IsFromCookie(ctx context.Context) bool {
+	extractor, _ := ExtractorFromContext(ctx)
+	_, ok := extractor.(CookieExtractor)
+	return ok
+}
+
+type ctxPrincipalKey struct{}
+
+// NewPrincipalContext put the principal loaded by WithPrincipalLoader into context
+func NewPrincipalContext[P any](ctx context.Context, principal P) context.Context {
+	return context.WithValue(ctx, ctxPrincipalKey{}, principal)
+}
+
+// PrincipalFromContext extract the principal loaded by WithPrincipalLoader from context
+func PrincipalFromContext[P any](ctx context.Context) (principal P, ok bool) {
+	principal, ok = ctx.Value(ctxPrincipalKey{}).(P)
+	return
+}
+
+type ctxTenantKey struct{}
+
+// NewTenantContext put the tenant id resolved by MultiTenant into context
+func NewTenantContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxTenantKey{}, id)
+}
+
+// TenantFromContext extract the tenant id resolved by MultiTenant from context
+func TenantFromContext(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(ctxTenantKey{}).(string)
+	return
+}
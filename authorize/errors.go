@@ -2,6 +2,9 @@ package authorize
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
@@ -9,6 +12,80 @@ var (
 	ErrInvalidPubKey = errors.New("public key invalid")
 	// ErrInvalidPrivKey indicates that the given private key is invalid
 	ErrInvalidPrivKey = errors.New("private key invalid")
+	// ErrUnsupportedAlgorithm indicates the signing algorithm is not supported
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
 	// ErrMissingSecretKey indicates Secret key is required
 	ErrMissingSecretKey = errors.New("secret key is required")
+	// ErrMissingRevocationStore indicates revocation store is required
+	ErrMissingRevocationStore = errors.New("revocation store is required")
+	// ErrTokenRevoked indicates the token has been revoked
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrTokenInactive indicates the token is reported not active by the authorization server, see RFC 7662.
+	ErrTokenInactive = errors.New("token is not active")
+	// ErrTokenUseMismatch indicates the token is not the expected kind,
+	// such as a refresh token used as an access token.
+	ErrTokenUseMismatch = errors.New("token use mismatch")
+	// ErrRefreshTokenReused indicates an already rotated refresh token is used again,
+	// the whole token family has been revoked.
+	ErrRefreshTokenReused = errors.New("refresh token has been reused")
+	// ErrRefreshTokenNoFamily indicates the refresh token is not issued by GenerateTokenPair
+	// when FamilyStore is set, so it can not be rotated.
+	ErrRefreshTokenNoFamily = errors.New("refresh token has no family")
+	// ErrUnsupportedEncryption indicates the encryption algorithm is not supported
+	ErrUnsupportedEncryption = errors.New("unsupported encryption algorithm")
+	// ErrInvalidEncryptionKey indicates the encryption key is invalid
+	ErrInvalidEncryptionKey = errors.New("encryption key invalid")
+	// ErrTokenDecryption indicates the encrypted token can not be decrypted
+	ErrTokenDecryption = errors.New("token decryption failure")
+	// ErrInsufficientScope indicates the token does not grant the required scopes or roles
+	ErrInsufficientScope = errors.New("insufficient scope")
+	// ErrPrincipalDisabled can be returned by the principal loader when the account is disabled
+	ErrPrincipalDisabled = errors.New("principal is disabled")
+	// ErrInvalidCSRFToken indicates the CSRF token is missing or mismatched
+	ErrInvalidCSRFToken = errors.New("invalid csrf token")
+	// ErrInvalidDPoPProof indicates the DPoP proof is missing or invalid
+	ErrInvalidDPoPProof = errors.New("invalid dpop proof")
+	// ErrTokenBindingMismatch indicates the token is not presented by the sender it is bound to
+	ErrTokenBindingMismatch = errors.New("token binding mismatch")
+	// ErrUnknownTenant indicates the tenant of the token can not be resolved
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrUnknownClaim indicates the required claim in Config is not a registered claim
+	ErrUnknownClaim = errors.New("unknown registered claim")
+	// ErrInvalidSubjectCodec indicates the SubjectCodec does not encode the connId,
+	// which is checked unless Config.SkipConnIdCheck.
+	ErrInvalidSubjectCodec = errors.New("subject codec does not encode the connId")
+)
+
+// the errors of token parsing, same as jwt errors, so both can be used with errors.Is.
+var (
+	// ErrTokenMalformed indicates the token is malformed
+	ErrTokenMalformed = jwt.ErrTokenMalformed
+	// ErrTokenExpired indicates the token is expired
+	ErrTokenExpired = jwt.ErrTokenExpired
+	// ErrTokenNotValidYet indicates the token is not valid yet
+	ErrTokenNotValidYet = jwt.ErrTokenNotValidYet
+	// ErrTokenSignatureInvalid indicates the token signature is invalid
+	ErrTokenSignatureInvalid = jwt.ErrTokenSignatureInvalid
+)
+
+// the errors of claims validation, same as jwt errors, so both can be used with errors.Is.
+var (
+	// ErrInvalidIssuer indicates the token issuer is not one of the expected issuers
+	ErrInvalidIssuer = jwt.ErrTokenInvalidIssuer
+	// ErrInvalidAudience indicates the token audience contains none of the required audiences
+	ErrInvalidAudience = jwt.ErrTokenInvalidAudience
+	// ErrMissingClaim indicates the token is missing a required claim
+	ErrMissingClaim = jwt.ErrTokenRequiredClaimMissing
 )
+
+// ClaimError indicates a claim of the token is invalid.
+// Err is one of ErrInvalidIssuer, ErrInvalidAudience or ErrMissingClaim.
+type ClaimError struct {
+	// Claim the registered claim name, like "iss", "aud".
+	Claim string
+	Err   error
+}
+
+func (e *ClaimError) Error() string { return fmt.Sprintf("%s: %s", e.Err, e.Claim) }
+
+func (e *ClaimError) Unwrap() error { return e.Err }
@@ -0,0 +1,112 @@
+package authorize
+
+import (
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningMethodES256K the ECDSA using secp256k1 curve and SHA-256 (RFC 8812),
+// it is registered as "ES256K", the key is *secp256k1.PrivateKey and *secp256k1.PublicKey.
+var SigningMethodES256K jwt.SigningMethod = &signingMethodES256K{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodES256K.Alg(), func() jwt.SigningMethod {
+		return SigningMethodES256K
+	})
+}
+
+type signingMethodES256K struct{}
+
+func (*signingMethodES256K) Alg() string { return "ES256K" }
+
+// Sign implement jwt.SigningMethod interface, returns R || S.
+func (*signingMethodES256K) Sign(signingString string, key any) ([]byte, error) {
+	priv, ok := key.(*secp256k1.PrivateKey)
+	if !ok {
+		return nil, jwt.ErrInvalidKeyType
+	}
+	digest := sha256.Sum256([]byte(signingString))
+	sig := secp256k1ecdsa.Sign(priv, digest[:])
+	r, s := sig.R(), sig.S()
+	out := make([]byte, 64)
+	r.PutBytesUnchecked(out[:32])
+	s.PutBytesUnchecked(out[32:])
+	return out, nil
+}
+
+// Verify implement jwt.SigningMethod interface.
+func (*signingMethodES256K) Verify(signingString string, sig []byte, key any) error {
+	pub, ok := key.(*secp256k1.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	if len(sig) != 64 {
+		return jwt.ErrECDSAVerification
+	}
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) || r.IsZero() || s.IsZero() {
+		return jwt.ErrECDSAVerification
+	}
+	digest := sha256.Sum256([]byte(signingString))
+	if !secp256k1ecdsa.NewSignature(&r, &s).Verify(digest[:], pub) {
+		return jwt.ErrECDSAVerification
+	}
+	return nil
+}
+
+var (
+	oidPublicKeyECDSA  = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidNamedCurveS256K = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// ecPrivateKey the SEC 1 private key structure.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkixPublicKey the PKIX public key structure.
+type pkixPublicKey struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// parseSecp256k1PrivateKey parses the SEC 1 DER encoded secp256k1 private key.
+func parseSecp256k1PrivateKey(der []byte) (*secp256k1.PrivateKey, error) {
+	var key ecPrivateKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	if !key.NamedCurveOID.Equal(oidNamedCurveS256K) {
+		return nil, errors.New("not a secp256k1 key")
+	}
+	if len(key.PrivateKey) != 32 {
+		return nil, fmt.Errorf("invalid secp256k1 private key size %d", len(key.PrivateKey))
+	}
+	return secp256k1.PrivKeyFromBytes(key.PrivateKey), nil
+}
+
+// parseSecp256k1PublicKey parses the PKIX DER encoded secp256k1 public key.
+func parseSecp256k1PublicKey(der []byte) (*secp256k1.PublicKey, error) {
+	var key pkixPublicKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	var curve asn1.ObjectIdentifier
+	if !key.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.New("not an ecdsa key")
+	}
+	if _, err := asn1.Unmarshal(key.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidNamedCurveS256K) {
+		return nil, errors.New("not a secp256k1 key")
+	}
+	return secp256k1.ParsePubKey(key.PublicKey.RightAlign())
+}
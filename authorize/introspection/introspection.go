@@ -0,0 +1,237 @@
+// Package introspection implements an authorize.Validator for opaque access tokens
+// with OAuth 2.0 Token Introspection (RFC 7662).
+package introspection
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/things-go/gin-contrib/authorize"
+)
+
+// ErrTokenInactive the introspection endpoint reports the token is not active.
+var ErrTokenInactive = authorize.ErrTokenInactive
+
+// Response the introspection response of RFC 7662.
+type Response struct {
+	Active    bool             `json:"active"`
+	Scope     string           `json:"scope,omitempty"`
+	ClientID  string           `json:"client_id,omitempty"`
+	Username  string           `json:"username,omitempty"`
+	TokenType string           `json:"token_type,omitempty"`
+	ExpiresAt *jwt.NumericDate `json:"exp,omitempty"`
+	IssuedAt  *jwt.NumericDate `json:"iat,omitempty"`
+	NotBefore *jwt.NumericDate `json:"nbf,omitempty"`
+	Subject   string           `json:"sub,omitempty"`
+	Audience  jwt.ClaimStrings `json:"aud,omitempty"`
+	Issuer    string           `json:"iss,omitempty"`
+	ID        string           `json:"jti,omitempty"`
+	// Raw the raw json of the response, which contains the extension fields.
+	Raw json.RawMessage `json:"-"`
+}
+
+// Config the config of Client.
+type Config[T any] struct {
+	// Endpoint the introspection endpoint.
+	// Required.
+	Endpoint string
+	// ClientID, ClientSecret the credentials which authenticate to the endpoint with http basic authentication.
+	// Optional.
+	ClientID, ClientSecret string
+	// BearerToken the token which authenticates to the endpoint, used when ClientID is empty.
+	// Optional.
+	BearerToken string
+	// TokenTypeHint the `token_type_hint` parameter.
+	// Optional, Default "access_token".
+	TokenTypeHint string
+	// HTTPClient the http client used to call the endpoint.
+	// Optional, Default &http.Client{Timeout: 10 * time.Second}.
+	HTTPClient *http.Client
+	// CacheTTL the max duration of caching the active result, it is cached until `exp` at most.
+	// Optional, Default 5 minutes, if < 0, disable caching.
+	CacheTTL time.Duration
+	// NegativeCacheTTL the duration of caching the inactive result.
+	// Optional, Default 10 seconds, if < 0, disable caching.
+	NegativeCacheTTL time.Duration
+	// Mapper maps the active response into the claims.
+	// Optional, Default unmarshals Response.Raw into the claims, the extension field "meta" is mapped to Claims.Meta.
+	// the claims whose TokenUse is not empty or access is rejected with authorize.ErrTokenUseMismatch.
+	Mapper func(resp *Response) (*authorize.Claims[T], error)
+}
+
+// Client the introspection client, it implements authorize.Validator.
+type Client[T any] struct {
+	cfg   Config[T]
+	cache *cache.Cache
+	group singleflight.Group
+}
+
+// New new introspection client with Config.
+func New[T any](c Config[T]) (*Client[T], error) {
+	if c.Endpoint == "" {
+		return nil, errors.New("introspection: endpoint is required")
+	}
+	if _, err := url.Parse(c.Endpoint); err != nil {
+		return nil, fmt.Errorf("introspection: invalid endpoint, %w", err)
+	}
+	if c.TokenTypeHint == "" {
+		c.TokenTypeHint = "access_token"
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = 5 * time.Minute
+	}
+	if c.NegativeCacheTTL == 0 {
+		c.NegativeCacheTTL = 10 * time.Second
+	}
+	if c.Mapper == nil {
+		c.Mapper = defaultMapper[T]
+	}
+	return &Client[T]{
+		cfg:   c,
+		cache: cache.New(cache.NoExpiration, time.Minute),
+	}, nil
+}
+
+// Validate implement authorize.Validator interface.
+// the result is cached by the hash of the token, the active result until `exp` or CacheTTL,
+// the inactive result for NegativeCacheTTL, the failure of the endpoint is never cached.
+func (c *Client[T]) Validate(ctx context.Context, token string) (*authorize.Claims[T], error) {
+	key := cacheKey(token)
+	if val, found := c.cache.Get(key); found {
+		return c.result(val.(*Response))
+	}
+	val, err, _ := c.group.Do(key, func() (any, error) {
+		// shared by the concurrent requests, so it is not canceled with the first one,
+		// HTTPClient.Timeout still bounds it.
+		resp, err := c.Introspect(context.WithoutCancel(ctx), token)
+		if err != nil {
+			return nil, err
+		}
+		if ttl := c.ttl(resp); ttl > 0 {
+			c.cache.Set(key, resp, ttl)
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.result(val.(*Response))
+}
+
+// Introspect calls the introspection endpoint, it is not cached.
+func (c *Client[T]) Introspect(ctx context.Context, token string) (*Response, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {c.cfg.TokenTypeHint},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	} else if c.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.BearerToken)
+	}
+	res, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspection: request failure, %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("introspection: read response failure, %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection: unexpected status %d", res.StatusCode)
+	}
+	resp := &Response{}
+	if err = json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("introspection: invalid response, %w", err)
+	}
+	resp.Raw = body
+	return resp, nil
+}
+
+// result returns the claims of the active access token, the refresh token is rejected
+// with authorize.ErrTokenUseMismatch, by either `token_type` or `token_use`.
+func (c *Client[T]) result(resp *Response) (*authorize.Claims[T], error) {
+	if !resp.Active {
+		return nil, ErrTokenInactive
+	}
+	if !isAccessTokenType(resp.TokenType) {
+		return nil, fmt.Errorf("%w: token type %q", authorize.ErrTokenUseMismatch, resp.TokenType)
+	}
+	now := time.Now()
+	if resp.ExpiresAt != nil && !now.Before(resp.ExpiresAt.Time) {
+		return nil, authorize.ErrTokenExpired
+	}
+	if resp.NotBefore != nil && now.Before(resp.NotBefore.Time) {
+		return nil, authorize.ErrTokenNotValidYet
+	}
+	claims, err := c.cfg.Mapper(resp)
+	if err != nil {
+		return nil, err
+	}
+	switch claims.TokenUse {
+	case "":
+		claims.TokenUse = authorize.TokenUseAccess
+	case authorize.TokenUseAccess:
+	default:
+		return nil, fmt.Errorf("%w: token use %q", authorize.ErrTokenUseMismatch, claims.TokenUse)
+	}
+	return claims, nil
+}
+
+// isAccessTokenType reports whether the `token_type` is empty or the type of an access token (RFC 6749 section 7.1).
+func isAccessTokenType(tokenType string) bool {
+	switch strings.ToLower(tokenType) {
+	case "", "bearer", "dpop", "access_token":
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client[T]) ttl(resp *Response) time.Duration {
+	if !resp.Active {
+		return c.cfg.NegativeCacheTTL
+	}
+	ttl := c.cfg.CacheTTL
+	if resp.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(resp.ExpiresAt.Time))
+	}
+	return ttl
+}
+
+func defaultMapper[T any](resp *Response) (*authorize.Claims[T], error) {
+	claims := &authorize.Claims[T]{}
+	if err := json.Unmarshal(resp.Raw, claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", authorize.ErrTokenMalformed, err)
+	}
+	return claims, nil
+}
+
+func cacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,190 @@
+package introspection
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize"
+)
+
+type testMeta struct {
+	Tenant string `json:"tenant"`
+}
+
+func newTestServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "rs" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "access_token", r.PostForm.Get("token_type_hint"))
+		var resp map[string]any
+		switch r.PostForm.Get("token") {
+		case "active":
+			resp = map[string]any{
+				"active": true,
+				"sub":    "alice",
+				"scope":  "read write",
+				"aud":    "api",
+				"exp":    time.Now().Add(time.Hour).Unix(),
+				"jti":    "1",
+				"meta":   map[string]any{"tenant": "acme"},
+			}
+		case "refresh_use":
+			resp = map[string]any{"active": true, "sub": "alice", "token_use": "refresh"}
+		case "refresh_type":
+			resp = map[string]any{"active": true, "sub": "alice", "token_type": "refresh_token"}
+		case "bearer_type":
+			resp = map[string]any{"active": true, "sub": "alice", "token_type": "Bearer", "token_use": "access"}
+		case "expired":
+			resp = map[string]any{"active": true, "sub": "alice", "exp": time.Now().Add(-time.Minute).Unix()}
+		case "error":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		default:
+			resp = map[string]any{"active": false}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, calls *atomic.Int32) *Client[testMeta] {
+	c, err := New[testMeta](Config[testMeta]{
+		Endpoint:     newTestServer(t, calls).URL,
+		ClientID:     "rs",
+		ClientSecret: "secret",
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func Test_Validate(t *testing.T) {
+	calls := &atomic.Int32{}
+	c := newTestClient(t, calls)
+
+	claims, err := c.Validate(context.Background(), "active")
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+	require.Equal(t, "1", claims.ID)
+	require.Equal(t, []string{"api"}, []string(claims.Audience))
+	require.True(t, claims.HasScopes("read", "write"))
+	require.Equal(t, "acme", claims.Meta.Tenant)
+	require.Equal(t, authorize.TokenUseAccess, claims.TokenUse)
+
+	// positive result is cached.
+	_, err = c.Validate(context.Background(), "active")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), calls.Load())
+
+	// negative result is cached.
+	_, err = c.Validate(context.Background(), "inactive")
+	require.ErrorIs(t, err, ErrTokenInactive)
+	_, err = c.Validate(context.Background(), "inactive")
+	require.ErrorIs(t, err, ErrTokenInactive)
+	require.Equal(t, int32(2), calls.Load())
+
+	_, err = c.Validate(context.Background(), "expired")
+	require.ErrorIs(t, err, authorize.ErrTokenExpired)
+
+	// refresh token is not accepted as access token.
+	_, err = c.Validate(context.Background(), "refresh_use")
+	require.ErrorIs(t, err, authorize.ErrTokenUseMismatch)
+	_, err = c.Validate(context.Background(), "refresh_type")
+	require.ErrorIs(t, err, authorize.ErrTokenUseMismatch)
+	claims, err = c.Validate(context.Background(), "bearer_type")
+	require.NoError(t, err)
+	require.Equal(t, authorize.TokenUseAccess, claims.TokenUse)
+
+	// failure of the endpoint is not cached.
+	_, err = c.Validate(context.Background(), "error")
+	require.Error(t, err)
+	_, err = c.Validate(context.Background(), "error")
+	require.Error(t, err)
+	require.Equal(t, int32(8), calls.Load())
+}
+
+func Test_Validate_NoCache(t *testing.T) {
+	calls := &atomic.Int32{}
+	c, err := New[testMeta](Config[testMeta]{
+		Endpoint:         newTestServer(t, calls).URL,
+		ClientID:         "rs",
+		ClientSecret:     "secret",
+		CacheTTL:         -1,
+		NegativeCacheTTL: -1,
+	})
+	require.NoError(t, err)
+	for range 2 {
+		_, err = c.Validate(context.Background(), "active")
+		require.NoError(t, err)
+		_, err = c.Validate(context.Background(), "inactive")
+		require.ErrorIs(t, err, ErrTokenInactive)
+	}
+	require.Equal(t, int32(4), calls.Load())
+}
+
+func Test_New(t *testing.T) {
+	_, err := New[testMeta](Config[testMeta]{})
+	require.Error(t, err)
+}
+
+func Test_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := newTestClient(t, &atomic.Int32{})
+
+	router := gin.New()
+	router.GET("/", authorize.NewMiddleware[testMeta](authorize.NewLookup("header:Authorization:Bearer,query:token"), c),
+		authorize.RequireScopes[testMeta]("read"),
+		func(c *gin.Context) {
+			claims, ok := authorize.FromContext[testMeta](c.Request.Context())
+			require.True(t, ok)
+			c.String(http.StatusOK, claims.Subject)
+		})
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"header", "/", "active", http.StatusOK},
+		{"query", "/?token=active", "", http.StatusOK},
+		{"inactive", "/", "inactive", http.StatusUnauthorized},
+		{"missing", "/", "", http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized {
+				require.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func Test_Validate_Canceled(t *testing.T) {
+	calls := &atomic.Int32{}
+	c := newTestClient(t, calls)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the endpoint call is detached from the canceled request.
+	claims, err := c.Validate(ctx, "active")
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+}
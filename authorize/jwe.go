@@ -0,0 +1,210 @@
+package authorize
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// EncryptionConfig the config of encrypting the signed token into a JWE (RFC 7516),
+// so the claims are not readable by the client.
+type EncryptionConfig struct {
+	// Algorithm key management algorithm.
+	// Required, Possible values: "dir", "RSA-OAEP", "RSA-OAEP-256".
+	Algorithm string
+	// Encryption content encryption algorithm.
+	// Optional, Default A256GCM, Possible values: "A128GCM", "A192GCM", "A256GCM".
+	Encryption string
+	// Key the shared symmetric key, its size must match the Encryption, such as 32 bytes for A256GCM.
+	// Required, if Algorithm is "dir".
+	Key []byte
+	// PrivateKey the private key used to decrypt, PublicKey the public key used to encrypt,
+	// use LoadPrivateKeyFile, ParsePrivateKeyPEM and so on to load them.
+	// Required, PrivateKey or PrivKey, if Algorithm is one of "RSA-OAEP", "RSA-OAEP-256".
+	// Optional, PublicKey, default the public key of the private key.
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+	// Private key used to decrypt, Public key used to encrypt.
+	// they are base64, file path or PEM, which is guessed by trial.
+	// Deprecated: use PrivateKey and PublicKey instead.
+	PrivKey, PubKey string
+}
+
+// jweHeader the JOSE header of JWE.
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+}
+
+// encrypter encrypts and decrypts JWE compact serialization.
+type encrypter struct {
+	alg     string
+	enc     string
+	keySize int
+	key     []byte
+	privKey *rsa.PrivateKey
+	pubKey  *rsa.PublicKey
+	hash    func() hash.Hash
+}
+
+func newEncrypter(c *EncryptionConfig) (*encrypter, error) {
+	e := &encrypter{
+		alg: c.Algorithm,
+		enc: c.Encryption,
+	}
+	switch e.enc {
+	case "A128GCM":
+		e.keySize = 16
+	case "A192GCM":
+		e.keySize = 24
+	case "", "A256GCM":
+		e.enc, e.keySize = "A256GCM", 32
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncryption, e.enc)
+	}
+	switch e.alg {
+	case "dir":
+		if len(c.Key) != e.keySize {
+			return nil, ErrInvalidEncryptionKey
+		}
+		e.key = c.Key
+	case "RSA-OAEP", "RSA-OAEP-256":
+		var err error
+
+		e.hash = sha1.New
+		if e.alg == "RSA-OAEP-256" {
+			e.hash = sha256.New
+		}
+		e.privKey, e.pubKey = c.PrivateKey, c.PublicKey
+		if e.privKey == nil {
+			e.privKey, err = parseRSAPrivateKey(c.PrivKey)
+			if err != nil {
+				return nil, ErrInvalidPrivKey
+			}
+		}
+		if e.pubKey == nil {
+			e.pubKey = &e.privKey.PublicKey
+			if c.PubKey != "" {
+				e.pubKey, err = parseRSAPublicKey(c.PubKey)
+				if err != nil {
+					return nil, ErrInvalidPubKey
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncryption, e.alg)
+	}
+	return e, nil
+}
+
+// Encrypt encrypts the signed token into JWE compact serialization.
+func (e *encrypter) Encrypt(token string) (string, error) {
+	header, err := json.Marshal(jweHeader{Alg: e.alg, Enc: e.enc, Cty: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	cek, encryptedKey := e.key, []byte{}
+	if e.alg != "dir" {
+		cek = make([]byte, e.keySize)
+		if _, err = rand.Read(cek); err != nil {
+			return "", err
+		}
+		encryptedKey, err = rsa.EncryptOAEP(e.hash(), rand.Reader, e.pubKey, cek, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(header)
+	sealed := aead.Seal(nil, iv, []byte(token), []byte(protected))
+	tagOffset := len(sealed) - aead.Overhead()
+	return strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(sealed[:tagOffset]),
+		base64.RawURLEncoding.EncodeToString(sealed[tagOffset:]),
+	}, "."), nil
+}
+
+// Decrypt decrypts the JWE compact serialization into the signed token.
+func (e *encrypter) Decrypt(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return "", fmt.Errorf("%w: token is not encrypted", ErrTokenMalformed)
+	}
+	raw := make([][]byte, 0, len(parts))
+	for _, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+		}
+		raw = append(raw, b)
+	}
+	var header jweHeader
+	if err := json.Unmarshal(raw[0], &header); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	}
+	// only accept the configured algorithms, avoid algorithm confusion.
+	if header.Alg != e.alg || header.Enc != e.enc {
+		return "", fmt.Errorf("%w: unexpected encryption %s/%s", ErrTokenMalformed, header.Alg, header.Enc)
+	}
+	// the iv and tag are checked before the key is decrypted, so the result does not depend on it.
+	if len(raw[2]) != gcmNonceSize || len(raw[4]) != gcmTagSize {
+		return "", fmt.Errorf("%w: invalid iv or tag", ErrTokenMalformed)
+	}
+	cek := e.key
+	if e.alg != "dir" {
+		// RFC 7516 section 11.5, a random key is used if the encrypted key can not be decrypted,
+		// so it fails the same way as the invalid ciphertext, not an oracle of the RSA decryption.
+		cek = make([]byte, e.keySize)
+		if _, err := rand.Read(cek); err != nil {
+			return "", err
+		}
+		key, err := rsa.DecryptOAEP(e.hash(), nil, e.privKey, raw[1], nil)
+		if err == nil && len(key) == e.keySize {
+			cek = key
+		}
+	} else if len(raw[1]) != 0 {
+		return "", fmt.Errorf("%w: unexpected encrypted key", ErrTokenMalformed)
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := aead.Open(nil, raw[2], append(raw[3], raw[4]...), []byte(parts[0]))
+	if err != nil {
+		return "", ErrTokenDecryption
+	}
+	return string(plaintext), nil
+}
+
+// the nonce size and the tag size of the standard GCM, used by newGCM.
+const (
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptionKey, err)
+	}
+	return cipher.NewGCM(block)
+}
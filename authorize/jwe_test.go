@@ -0,0 +1,75 @@
+package authorize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_Encryption(t *testing.T) {
+	privKey, pubKey := newTestRSAKey(t)
+
+	tests := []struct {
+		name       string
+		encryption *EncryptionConfig
+	}{
+		{"dir A256GCM", &EncryptionConfig{Algorithm: "dir", Key: []byte("0123456789abcdef0123456789abcdef")}},
+		{"dir A128GCM", &EncryptionConfig{Algorithm: "dir", Encryption: "A128GCM", Key: []byte("0123456789abcdef")}},
+		{"RSA-OAEP", &EncryptionConfig{Algorithm: "RSA-OAEP", PrivKey: privKey}},
+		{"RSA-OAEP-256", &EncryptionConfig{Algorithm: "RSA-OAEP-256", PrivKey: privKey, PubKey: pubKey}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := newTestAuth(t, Config{Encryption: tt.encryption})
+			tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+			require.NoError(t, err)
+			require.Len(t, strings.Split(tk, "."), 5)
+
+			claims, err := auth.ParseToken(tk)
+			require.NoError(t, err)
+			require.Equal(t, "alice", claims.Subject)
+			require.Equal(t, "test", claims.Meta.Username)
+
+			// tampered ciphertext
+			parts := strings.Split(tk, ".")
+			parts[3] = strings.Repeat("A", len(parts[3]))
+			_, err = auth.ParseToken(strings.Join(parts, "."))
+			require.ErrorIs(t, err, ErrTokenDecryption)
+		})
+	}
+
+	t.Run("tampered encrypted key", func(t *testing.T) {
+		auth := newTestAuth(t, Config{Encryption: tests[2].encryption})
+		tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		parts := strings.Split(tk, ".")
+		_, tamperedCiphertext := auth.ParseToken(strings.Join([]string{parts[0], parts[1], parts[2], strings.Repeat("A", len(parts[3])), parts[4]}, "."))
+		// the encrypted key fails the same way as the ciphertext.
+		parts[1] = strings.Repeat("A", len(parts[1]))
+		_, err = auth.ParseToken(strings.Join(parts, "."))
+		require.ErrorIs(t, err, ErrTokenDecryption)
+		require.Equal(t, tamperedCiphertext.Error(), err.Error())
+	})
+
+	t.Run("reject not encrypted token", func(t *testing.T) {
+		tk, _, err := newTestAuth(t, Config{}).GenerateToken(newTestClaims("1", "alice"))
+		require.NoError(t, err)
+		auth := newTestAuth(t, Config{Encryption: tests[0].encryption})
+		_, err = auth.ParseToken(tk)
+		require.ErrorIs(t, err, ErrTokenMalformed)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := New[*testAccount](Config{
+			Key:        []byte("testSecretKey"),
+			Encryption: &EncryptionConfig{Algorithm: "dir", Key: []byte("short")},
+		})
+		require.ErrorIs(t, err, ErrInvalidEncryptionKey)
+		_, err = New[*testAccount](Config{
+			Key:        []byte("testSecretKey"),
+			Encryption: &EncryptionConfig{Algorithm: "A256KW"},
+		})
+		require.ErrorIs(t, err, ErrUnsupportedEncryption)
+	})
+}
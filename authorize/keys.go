@@ -0,0 +1,388 @@
+package authorize
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// LoadPrivateKeyFile loads the private key from the file, which contains a PEM block or a JWK.
+// see ParsePrivateKeyPEM and ParsePrivateKeyJWK.
+func LoadPrivateKeyFile(path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: read file %s, %w", ErrInvalidPrivKey, path, err)
+	}
+	return parsePrivateKey(data)
+}
+
+// LoadPrivateKeyEnv loads the private key from the environment variable, which contains a PEM block or a JWK.
+// see ParsePrivateKeyPEM and ParsePrivateKeyJWK.
+func LoadPrivateKeyEnv(name string) (crypto.PrivateKey, error) {
+	data := os.Getenv(name)
+	if data == "" {
+		return nil, fmt.Errorf("%w: environment variable %s is empty", ErrInvalidPrivKey, name)
+	}
+	return parsePrivateKey([]byte(data))
+}
+
+// LoadPublicKeyFile loads the public key from the file, which contains a PEM block or a JWK.
+// see ParsePublicKeyPEM and ParsePublicKeyJWK.
+func LoadPublicKeyFile(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: read file %s, %w", ErrInvalidPubKey, path, err)
+	}
+	return parsePublicKey(data)
+}
+
+// LoadPublicKeyEnv loads the public key from the environment variable, which contains a PEM block or a JWK.
+// see ParsePublicKeyPEM and ParsePublicKeyJWK.
+func LoadPublicKeyEnv(name string) (crypto.PublicKey, error) {
+	data := os.Getenv(name)
+	if data == "" {
+		return nil, fmt.Errorf("%w: environment variable %s is empty", ErrInvalidPubKey, name)
+	}
+	return parsePublicKey([]byte(data))
+}
+
+// ParsePrivateKeyPEM parses the private key of the first PEM block.
+// Possible block types: "PRIVATE KEY" (PKCS#8), "RSA PRIVATE KEY" (PKCS#1), "EC PRIVATE KEY" (SEC 1, include secp256k1).
+func ParsePrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidPrivKey)
+	}
+	var (
+		key crypto.PrivateKey
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		return ParsePrivateKeyPKCS8(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			if k, e := parseSecp256k1PrivateKey(block.Bytes); e == nil {
+				key, err = k, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM block type %q", ErrInvalidPrivKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s, %w", ErrInvalidPrivKey, block.Type, err)
+	}
+	return key, nil
+}
+
+// ParsePrivateKeyPKCS8 parses the PKCS#8 DER encoded private key, the key is one of
+// *rsa.PrivateKey, *ecdsa.PrivateKey and ed25519.PrivateKey.
+func ParsePrivateKeyPKCS8(der []byte) (crypto.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PKCS#8, %w", ErrInvalidPrivKey, err)
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported PKCS#8 key type %T", ErrInvalidPrivKey, key)
+	}
+}
+
+// ParsePublicKeyPEM parses the public key of the first PEM block.
+// Possible block types: "PUBLIC KEY" (PKIX, include secp256k1), "RSA PUBLIC KEY" (PKCS#1), "CERTIFICATE".
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidPubKey)
+	}
+	var (
+		key crypto.PublicKey
+		err error
+	)
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			if k, e := parseSecp256k1PublicKey(block.Bytes); e == nil {
+				key, err = k, nil
+			}
+		}
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM block type %q", ErrInvalidPubKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s, %w", ErrInvalidPubKey, block.Type, err)
+	}
+	return key, nil
+}
+
+// jwk the JSON Web Key (RFC 7517) of RSA, EC and OKP (Ed25519) keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	// RSA
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	Dp string `json:"dp,omitempty"`
+	Dq string `json:"dq,omitempty"`
+	Qi string `json:"qi,omitempty"`
+	// EC and OKP
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+	// private exponent of RSA, private key of EC and OKP.
+	D string `json:"d,omitempty"`
+}
+
+// ParsePrivateKeyJWK parses the private key of the JWK (RFC 7517),
+// Possible key types: "RSA", "EC" (P-256, P-384, P-521, secp256k1), "OKP" (Ed25519).
+func ParsePrivateKeyJWK(data []byte) (crypto.PrivateKey, error) {
+	key, err := parseJWK(data, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: JWK, %w", ErrInvalidPrivKey, err)
+	}
+	return key, nil
+}
+
+// ParsePublicKeyJWK parses the public key of the JWK (RFC 7517),
+// Possible key types: "RSA", "EC" (P-256, P-384, P-521, secp256k1), "OKP" (Ed25519).
+func ParsePublicKeyJWK(data []byte) (crypto.PublicKey, error) {
+	key, err := parseJWK(data, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: JWK, %w", ErrInvalidPubKey, err)
+	}
+	return key, nil
+}
+
+func parseJWK(data []byte, private bool) (any, error) {
+	var k jwk
+
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+	if private && k.D == "" {
+		return nil, fmt.Errorf("missing private key member \"d\"")
+	}
+	b := &jwkDecoder{}
+	switch k.Kty {
+	case "RSA":
+		pub := &rsa.PublicKey{N: b.bigInt("n", k.N)}
+		if e := b.bigInt("e", k.E); e != nil {
+			if !e.IsInt64() || e.Int64() > 1<<31-1 {
+				return nil, fmt.Errorf("invalid member \"e\"")
+			}
+			pub.E = int(e.Int64())
+		}
+		if b.err != nil {
+			return nil, b.err
+		}
+		if !private {
+			return pub, nil
+		}
+		priv := &rsa.PrivateKey{PublicKey: *pub, D: b.bigInt("d", k.D)}
+		if k.P != "" && k.Q != "" {
+			priv.Primes = []*big.Int{b.bigInt("p", k.P), b.bigInt("q", k.Q)}
+		}
+		if b.err != nil {
+			return nil, b.err
+		}
+		if len(priv.Primes) == 0 {
+			return nil, fmt.Errorf("missing member \"p\" or \"q\"")
+		}
+		if err := priv.Validate(); err != nil {
+			return nil, err
+		}
+		priv.Precompute()
+		return priv, nil
+	case "EC":
+		if k.Crv == "secp256k1" {
+			return parseSecp256k1JWK(b, &k, private)
+		}
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: b.bigInt("x", k.X), Y: b.bigInt("y", k.Y)}
+		if b.err != nil {
+			return nil, b.err
+		}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		if !private {
+			return pub, nil
+		}
+		d := b.bytes("d", k.D)
+		if b.err != nil {
+			return nil, b.err
+		}
+		if err := checkECPrivateKey(pub, d); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PrivateKey{PublicKey: *pub, D: new(big.Int).SetBytes(d)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x := b.bytes("x", k.X)
+		if b.err != nil {
+			return nil, b.err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid member \"x\" size")
+		}
+		if !private {
+			return ed25519.PublicKey(x), nil
+		}
+		d := b.bytes("d", k.D)
+		if b.err != nil {
+			return nil, b.err
+		}
+		if len(d) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid member \"d\" size")
+		}
+		priv := ed25519.NewKeyFromSeed(d)
+		if !bytes.Equal(priv.Public().(ed25519.PublicKey), x) {
+			return nil, fmt.Errorf("member \"x\" does not match member \"d\"")
+		}
+		return priv, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// checkECPrivateKey checks the public point derived from the private scalar d is the public key,
+// d without the leading zeros is accepted.
+func checkECPrivateKey(pub *ecdsa.PublicKey, d []byte) error {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	if len(d) > size {
+		return fmt.Errorf("invalid member \"d\" size")
+	}
+	ecdhPub, err := pub.ECDH()
+	if err != nil {
+		return err
+	}
+	scalar := make([]byte, size)
+	copy(scalar[size-len(d):], d)
+	priv, err := ecdhPub.Curve().NewPrivateKey(scalar)
+	if err != nil {
+		return fmt.Errorf("invalid member \"d\", %w", err)
+	}
+	if !priv.PublicKey().Equal(ecdhPub) {
+		return fmt.Errorf("member \"x\" or \"y\" does not match member \"d\"")
+	}
+	return nil
+}
+
+func parseSecp256k1JWK(b *jwkDecoder, k *jwk, private bool) (any, error) {
+	x, y := b.bytes("x", k.X), b.bytes("y", k.Y)
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, fmt.Errorf("invalid member \"x\" or \"y\" size")
+	}
+	pub, err := secp256k1.ParsePubKey(append(append([]byte{0x04}, x...), y...))
+	if err != nil {
+		return nil, err
+	}
+	if !private {
+		return pub, nil
+	}
+	d := b.bytes("d", k.D)
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(d) != 32 {
+		return nil, fmt.Errorf("invalid member \"d\" size")
+	}
+	priv := secp256k1.PrivKeyFromBytes(d)
+	if !priv.PubKey().IsEqual(pub) {
+		return nil, fmt.Errorf("member \"x\" or \"y\" does not match member \"d\"")
+	}
+	return priv, nil
+}
+
+// jwkDecoder decodes the base64url members of JWK, keeps the first error.
+type jwkDecoder struct {
+	err error
+}
+
+func (d *jwkDecoder) bytes(name, s string) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if s == "" {
+		d.err = fmt.Errorf("missing member %q", name)
+		return nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		d.err = fmt.Errorf("invalid member %q, %w", name, err)
+		return nil
+	}
+	return b
+}
+
+func (d *jwkDecoder) bigInt(name, s string) *big.Int {
+	b := d.bytes(name, s)
+	if b == nil {
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// parsePrivateKey parses the JWK if data is a json object, otherwise the PEM block.
+func parsePrivateKey(data []byte) (crypto.PrivateKey, error) {
+	if isJSONObject(data) {
+		return ParsePrivateKeyJWK(data)
+	}
+	return ParsePrivateKeyPEM(data)
+}
+
+// parsePublicKey parses the JWK if data is a json object, otherwise the PEM block.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	if isJSONObject(data) {
+		return ParsePublicKeyJWK(data)
+	}
+	return ParsePublicKeyPEM(data)
+}
+
+func isJSONObject(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
@@ -0,0 +1,219 @@
+package authorize
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func TestParsePrivateKeyPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecDer, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	for _, tt := range []struct {
+		name  string
+		block *pem.Block
+		want  any
+	}{
+		{"PKCS#1", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, rsaKey},
+		{"SEC 1", &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDer}, ecKey},
+		{"PKCS#8 ed25519", &pem.Block{Type: "PRIVATE KEY", Bytes: mustPKCS8(t, edKey)}, edKey},
+		{"PKCS#8 rsa", &pem.Block{Type: "PRIVATE KEY", Bytes: mustPKCS8(t, rsaKey)}, rsaKey},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePrivateKeyPEM(pem.EncodeToMemory(tt.block))
+			require.NoError(t, err)
+			require.True(t, keyEqual(tt.want, key))
+		})
+	}
+
+	_, err = ParsePrivateKeyPEM([]byte("not a pem"))
+	require.ErrorIs(t, err, ErrInvalidPrivKey)
+	_, err = ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1}}))
+	require.ErrorIs(t, err, ErrInvalidPrivKey)
+	require.Contains(t, err.Error(), `unsupported PEM block type "PUBLIC KEY"`)
+	_, err = ParsePrivateKeyPKCS8([]byte{1, 2, 3})
+	require.ErrorIs(t, err, ErrInvalidPrivKey)
+}
+
+func TestParsePublicKeyPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkix, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	key, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+	require.NoError(t, err)
+	require.True(t, rsaKey.PublicKey.Equal(key))
+	key, err = ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}))
+	require.NoError(t, err)
+	require.True(t, rsaKey.PublicKey.Equal(key))
+
+	_, err = ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}))
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+}
+
+func TestParseKeyJWK(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name string
+		jwk  map[string]string
+		priv any
+		pub  any
+	}{
+		{
+			name: "RSA",
+			jwk: map[string]string{
+				"kty": "RSA",
+				"n":   b64(rsaKey.N.Bytes()),
+				"e":   b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+				"d":   b64(rsaKey.D.Bytes()),
+				"p":   b64(rsaKey.Primes[0].Bytes()),
+				"q":   b64(rsaKey.Primes[1].Bytes()),
+			},
+			priv: rsaKey,
+			pub:  &rsaKey.PublicKey,
+		},
+		{
+			name: "EC",
+			jwk: map[string]string{
+				"kty": "EC",
+				"crv": "P-384",
+				"x":   b64(ecKey.X.Bytes()),
+				"y":   b64(ecKey.Y.Bytes()),
+				"d":   b64(ecKey.D.Bytes()),
+			},
+			priv: ecKey,
+			pub:  &ecKey.PublicKey,
+		},
+		{
+			name: "OKP",
+			jwk: map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"x":   b64(edPub),
+				"d":   b64(edKey.Seed()),
+			},
+			priv: edKey,
+			pub:  edPub,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.jwk)
+			require.NoError(t, err)
+			priv, err := ParsePrivateKeyJWK(data)
+			require.NoError(t, err)
+			require.True(t, keyEqual(tt.priv, priv))
+			pub, err := ParsePublicKeyJWK(data)
+			require.NoError(t, err)
+			require.True(t, keyEqual(tt.pub, pub))
+
+			// public jwk has no private key.
+			delete(tt.jwk, "d")
+			data, err = json.Marshal(tt.jwk)
+			require.NoError(t, err)
+			_, err = ParsePrivateKeyJWK(data)
+			require.ErrorIs(t, err, ErrInvalidPrivKey)
+		})
+	}
+
+	_, err = ParsePublicKeyJWK([]byte(`{"kty":"oct","k":"c2VjcmV0"}`))
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+	require.Contains(t, err.Error(), `unsupported key type "oct"`)
+	_, err = ParsePublicKeyJWK([]byte(`{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}`))
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+
+	// the private key does not match the public key.
+	otherKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	for _, d := range []*big.Int{otherKey.D, ecKey.Params().N} {
+		data, err := json.Marshal(map[string]string{
+			"kty": "EC",
+			"crv": "P-384",
+			"x":   b64(ecKey.X.Bytes()),
+			"y":   b64(ecKey.Y.Bytes()),
+			"d":   b64(d.Bytes()),
+		})
+		require.NoError(t, err)
+		_, err = ParsePrivateKeyJWK(data)
+		require.ErrorIs(t, err, ErrInvalidPrivKey)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pkix, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: mustPKCS8(t, ecKey)})
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "priv.pem"), privPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pub.pem"), pubPEM, 0o600))
+
+	priv, err := LoadPrivateKeyFile(filepath.Join(dir, "priv.pem"))
+	require.NoError(t, err)
+	require.True(t, ecKey.Equal(priv))
+	pub, err := LoadPublicKeyFile(filepath.Join(dir, "pub.pem"))
+	require.NoError(t, err)
+	require.True(t, ecKey.PublicKey.Equal(pub))
+	_, err = LoadPrivateKeyFile(filepath.Join(dir, "none.pem"))
+	require.ErrorIs(t, err, ErrInvalidPrivKey)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	t.Setenv("AUTHORIZE_TEST_PRIV_KEY", string(privPEM))
+	t.Setenv("AUTHORIZE_TEST_PUB_KEY", `{"kty":"EC","crv":"P-256","x":"`+b64(ecKey.X.Bytes())+`","y":"`+b64(ecKey.Y.Bytes())+`"}`)
+	priv, err = LoadPrivateKeyEnv("AUTHORIZE_TEST_PRIV_KEY")
+	require.NoError(t, err)
+	require.True(t, ecKey.Equal(priv))
+	pub, err = LoadPublicKeyEnv("AUTHORIZE_TEST_PUB_KEY")
+	require.NoError(t, err)
+	require.True(t, ecKey.PublicKey.Equal(pub))
+	_, err = LoadPublicKeyEnv("AUTHORIZE_TEST_NONE_KEY")
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+}
+
+func keyEqual(a, b any) bool {
+	switch k := a.(type) {
+	case interface{ Equal(crypto.PrivateKey) bool }:
+		return k.Equal(b)
+	case interface{ Equal(crypto.PublicKey) bool }:
+		return k.Equal(b)
+	default:
+		return false
+	}
+}
+
+func mustPKCS8(t *testing.T, key any) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return der
+}
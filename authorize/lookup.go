@@ -2,8 +2,11 @@ package authorize
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/gin-gonic/gin"
 )
 
 // ErrMissingValue can be thrown by follow
@@ -12,9 +15,14 @@ import (
 // if value with a cookie, the value cookie is empty
 var ErrMissingValue = errors.New("no value present in request")
 
+// ErrInvalidLookup indicates the lookup is invalid, such as unknown source
+var ErrInvalidLookup = errors.New("invalid lookup")
+
 // Lookup is a tool that looks up the value from http request, such as token
 type Lookup struct {
 	extractors MultiExtractor
+	// needParams the lookup contains ParamExtractor, which needs the gin route params.
+	needParams bool
 }
 
 // NewLookup new a lookup.
@@ -24,45 +32,103 @@ type Lookup struct {
 // Optional, Default value "header:Authorization:Bearer" for json web token.
 // Possible values:
 // - "header:<name>:<prefix>", <prefix> is a special string in the header, Possible value is "Bearer"
-// - "query:<name>"
+// - "query:<name>", the URL query or the POSTed form body
+// - "form:<name>", the POSTed form body only
 // - "cookie:<name>"
+// - "param:<name>", the gin route params
+// - "basic:<username|password>", the `Authorization: Basic` credential
+// - "websocket:<marker>", the `Sec-WebSocket-Protocol` which follows the marker protocol
+// NOTE: invalid lookup is ignored, use ParseLookup to report it.
 func NewLookup(lookup string) *Lookup {
+	l, _ := parseLookup(lookup)
+	return l
+}
+
+// ParseLookup likes NewLookup, but returns ErrInvalidLookup if any source is unknown or malformed.
+func ParseLookup(lookup string) (*Lookup, error) {
+	l, errs := parseLookup(lookup)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return l, nil
+}
+
+func parseLookup(lookup string) (*Lookup, []error) {
 	if lookup == "" {
 		lookup = "header:Authorization:Bearer"
 	}
+	var errs []error
+
 	methods := strings.Split(lookup, ",")
-	lookups := make(MultiExtractor, 0, len(methods))
+	l := &Lookup{extractors: make(MultiExtractor, 0, len(methods))}
 	for _, method := range methods {
-		parts := strings.Split(strings.TrimSpace(method), ":")
+		method = strings.TrimSpace(method)
+		parts := strings.Split(method, ":")
 		if len(parts) != 2 && len(parts) != 3 {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrInvalidLookup, method))
+			continue
+		}
+		if len(parts) == 3 && parts[0] != "header" {
+			errs = append(errs, fmt.Errorf("%w: prefix is only supported by header, %q", ErrInvalidLookup, method))
 			continue
 		}
+		name := strings.TrimSpace(parts[1])
 		switch parts[0] {
 		case "header":
 			prefix := ""
 			if len(parts) == 3 {
 				prefix = strings.TrimSpace(parts[2])
 			}
-			lookups = append(lookups, HeaderExtractor{strings.TrimSpace(parts[1]), prefix})
+			l.extractors = append(l.extractors, HeaderExtractor{name, prefix})
 		case "query":
-			lookups = append(lookups, ArgumentExtractor(parts[1]))
+			l.extractors = append(l.extractors, ArgumentExtractor(name))
+		case "form":
+			l.extractors = append(l.extractors, FormExtractor(name))
 		case "cookie":
-			lookups = append(lookups, CookieExtractor(parts[1]))
+			l.extractors = append(l.extractors, CookieExtractor(name))
+		case "param":
+			l.extractors = append(l.extractors, ParamExtractor(name))
+			l.needParams = true
+		case "basic":
+			if name != "username" && name != "password" {
+				errs = append(errs, fmt.Errorf("%w: basic must be username or password, %q", ErrInvalidLookup, method))
+				continue
+			}
+			l.extractors = append(l.extractors, BasicExtractor(name))
+		case "websocket":
+			l.extractors = append(l.extractors, WebSocketExtractor(name))
+		default:
+			errs = append(errs, fmt.Errorf("%w: unknown source, %q", ErrInvalidLookup, method))
 		}
 	}
-	if len(lookups) == 0 {
-		lookups = append(lookups, HeaderExtractor{"Authorization", "Bearer"})
+	if len(l.extractors) == 0 {
+		l.extractors = append(l.extractors, HeaderExtractor{"Authorization", "Bearer"})
+	}
+	return l, errs
+}
+
+// ExtractTokenFromGin extract value from gin context, it supports the gin route params.
+func (sf *Lookup) ExtractTokenFromGin(c *gin.Context) (string, Extractor, error) {
+	r := c.Request
+	if sf.needParams {
+		r = r.WithContext(NewParamsContext(r.Context(), c.Params))
 	}
-	return &Lookup{lookups}
+	return sf.Extract(r)
 }
 
 // ExtractToken extract value from http request.
 func (sf *Lookup) ExtractToken(r *http.Request) (string, error) {
-	token, err := sf.extractors.ExtractToken(r)
+	token, _, err := sf.Extract(r)
+	return token, err
+}
+
+// Extract extract value from http request, and returns the Extractor which the value comes from.
+func (sf *Lookup) Extract(r *http.Request) (string, Extractor, error) {
+	token, extractor, err := sf.extractors.Extract(r)
 	if err != nil || token == "" {
-		return "", ErrMissingValue
+		return "", nil, ErrMissingValue
 	}
-	return token, nil
+	return token, extractor, nil
 }
 
 // FromHeader get value from header
@@ -72,12 +138,18 @@ func FromHeader(r *http.Request, key, prefix string) (string, error) {
 	return HeaderExtractor{key, prefix}.ExtractToken(r)
 }
 
-// FromQuery get value from query
+// FromQuery get value from URL query or POSTed form body
 // key is a query key
 func FromQuery(r *http.Request, key string) (string, error) {
 	return ArgumentExtractor(key).ExtractToken(r)
 }
 
+// FromForm get value from POSTed form body
+// key is a form key
+func FromForm(r *http.Request, key string) (string, error) {
+	return FormExtractor(key).ExtractToken(r)
+}
+
 // FromCookie get value from Cookie
 // key is a cookie key
 func FromCookie(r *http.Request, key string) (string, error) {
@@ -1,10 +1,14 @@
 package authorize
 
 import (
+	"context"
 	"errors"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/gin-gonic/gin"
 )
 
 // Extractor is an interface for extracting a value from an HTTP request.
@@ -18,15 +22,21 @@ type Extractor interface {
 type MultiExtractor []Extractor
 
 func (e MultiExtractor) ExtractToken(req *http.Request) (string, error) {
+	tok, _, err := e.Extract(req)
+	return tok, err
+}
+
+// Extract likes ExtractToken, but also returns the Extractor which the value comes from.
+func (e MultiExtractor) Extract(req *http.Request) (string, Extractor, error) {
 	// loop over header names and return the first one that contains data
 	for _, extractor := range e {
 		if tok, err := extractor.ExtractToken(req); tok != "" {
-			return tok, nil
+			return tok, extractor, nil
 		} else if !errors.Is(err, ErrMissingValue) {
-			return "", err
+			return "", nil, err
 		}
 	}
-	return "", ErrMissingValue
+	return "", nil, ErrMissingValue
 }
 
 // HeaderExtractor is an extractor for finding a value in a header.
@@ -48,7 +58,8 @@ func (e HeaderExtractor) ExtractToken(r *http.Request) (string, error) {
 
 // ArgumentExtractor extracts a value from request arguments.  This includes a POSTed form or
 // GET URL arguments.
-// This extractor calls `ParseMultipartForm` on the request
+// This extractor calls `ParseMultipartForm` on the request,
+// prefer QueryExtractor or FormExtractor.
 type ArgumentExtractor string
 
 func (e ArgumentExtractor) ExtractToken(r *http.Request) (string, error) {
@@ -62,6 +73,104 @@ func (e ArgumentExtractor) ExtractToken(r *http.Request) (string, error) {
 	return "", ErrMissingValue
 }
 
+// QueryExtractor extracts a value from the URL query only.
+type QueryExtractor string
+
+func (e QueryExtractor) ExtractToken(r *http.Request) (string, error) {
+	tk := strings.TrimSpace(r.URL.Query().Get(string(e)))
+	if tk != "" {
+		return tk, nil
+	}
+	return "", ErrMissingValue
+}
+
+// FormExtractor extracts a value from the POSTed form body only.
+// The body is parsed only if the content type is "application/x-www-form-urlencoded"
+// or "multipart/form-data", so it does not parse every request.
+type FormExtractor string
+
+func (e FormExtractor) ExtractToken(r *http.Request) (string, error) {
+	if r.Body == nil {
+		return "", ErrMissingValue
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/x-www-form-urlencoded":
+		_ = r.ParseForm()
+	case "multipart/form-data":
+		_ = r.ParseMultipartForm(10e6)
+	default:
+		return "", ErrMissingValue
+	}
+	tk := strings.TrimSpace(r.PostForm.Get(string(e)))
+	if tk != "" {
+		return tk, nil
+	}
+	return "", ErrMissingValue
+}
+
+// ParamExtractor extracts a value from the gin route params.
+// The params must be put into the request context with NewParamsContext,
+// Middleware does it if the lookup contains "param:<name>".
+type ParamExtractor string
+
+func (e ParamExtractor) ExtractToken(r *http.Request) (string, error) {
+	params, _ := r.Context().Value(ctxParamsKey{}).(gin.Params)
+	tk := strings.TrimSpace(params.ByName(string(e)))
+	if tk != "" {
+		return tk, nil
+	}
+	return "", ErrMissingValue
+}
+
+type ctxParamsKey struct{}
+
+// NewParamsContext put the gin route params into context, which is used by ParamExtractor.
+func NewParamsContext(ctx context.Context, params gin.Params) context.Context {
+	return context.WithValue(ctx, ctxParamsKey{}, params)
+}
+
+// BasicExtractor extracts the credential from the `Authorization: Basic` header.
+// Possible value is "username" or "password", default "password",
+// such as "Basic base64(x-access-token:<token>)".
+type BasicExtractor string
+
+func (e BasicExtractor) ExtractToken(r *http.Request) (string, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return "", ErrMissingValue
+	}
+	tk := password
+	if e == "username" {
+		tk = username
+	}
+	if tk = strings.TrimSpace(tk); tk != "" {
+		return tk, nil
+	}
+	return "", ErrMissingValue
+}
+
+// WebSocketExtractor extracts a value from the `Sec-WebSocket-Protocol` header, as browsers can not set
+// other headers of the WebSocket handshake. The value is the protocol which follows the marker protocol,
+// such as "Sec-WebSocket-Protocol: access_token, <token>" with marker "access_token".
+// NOTE: the server should respond the marker protocol as the selected sub protocol.
+type WebSocketExtractor string
+
+func (e WebSocketExtractor) ExtractToken(r *http.Request) (string, error) {
+	var protocols []string
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(v, ",") {
+			protocols = append(protocols, strings.TrimSpace(protocol))
+		}
+	}
+	for i := 0; i < len(protocols)-1; i++ {
+		if protocols[i] == string(e) && protocols[i+1] != "" {
+			return protocols[i+1], nil
+		}
+	}
+	return "", ErrMissingValue
+}
+
 // CookieExtractor extracts a value from cookie.
 type CookieExtractor string
 
@@ -1,10 +1,16 @@
 package authorize
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExtractor(t *testing.T) {
@@ -74,6 +80,69 @@ func TestExtractor(t *testing.T) {
 			token:     "",
 			err:       ErrMissingValue,
 		},
+		{
+			name:      "query hit",
+			extractor: QueryExtractor("token"),
+			headers:   map[string]string{},
+			query:     url.Values{"token": {extractorTestTokenValue}},
+			cookie:    nil,
+			token:     extractorTestTokenValue,
+			err:       nil,
+		},
+		{
+			name:      "query miss",
+			extractor: QueryExtractor("token"),
+			headers:   map[string]string{},
+			query:     nil,
+			cookie:    nil,
+			token:     "",
+			err:       ErrMissingValue,
+		},
+		{
+			name:      "basic password hit",
+			extractor: BasicExtractor("password"),
+			headers:   map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+extractorTestTokenValue))},
+			query:     nil,
+			cookie:    nil,
+			token:     extractorTestTokenValue,
+			err:       nil,
+		},
+		{
+			name:      "basic username hit",
+			extractor: BasicExtractor("username"),
+			headers:   map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(extractorTestTokenValue+":"))},
+			query:     nil,
+			cookie:    nil,
+			token:     extractorTestTokenValue,
+			err:       nil,
+		},
+		{
+			name:      "basic miss",
+			extractor: BasicExtractor("password"),
+			headers:   map[string]string{"Authorization": "Bearer " + extractorTestTokenValue},
+			query:     nil,
+			cookie:    nil,
+			token:     "",
+			err:       ErrMissingValue,
+		},
+		{
+			name:      "websocket hit",
+			extractor: WebSocketExtractor("access_token"),
+			headers:   map[string]string{"Sec-WebSocket-Protocol": "chat, access_token, " + extractorTestTokenValue},
+			query:     nil,
+			cookie:    nil,
+			token:     extractorTestTokenValue,
+			err:       nil,
+		},
+		{
+			name:      "websocket miss",
+			extractor: WebSocketExtractor("access_token"),
+			headers:   map[string]string{"Sec-WebSocket-Protocol": "chat, access_token"},
+			query:     nil,
+			cookie:    nil,
+			token:     "",
+			err:       ErrMissingValue,
+		},
 		{
 			name:      "cookie hit",
 			extractor: CookieExtractor("token"),
@@ -120,6 +189,36 @@ func TestExtractor(t *testing.T) {
 	}
 }
 
+func TestFormExtractor(t *testing.T) {
+	newRequest := func(contentType string) *http.Request {
+		r, _ := http.NewRequestWithContext(context.TODO(), http.MethodPost, "/?token=query", strings.NewReader("token=form"))
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	tk, err := FormExtractor("token").ExtractToken(newRequest("application/x-www-form-urlencoded"))
+	require.NoError(t, err)
+	require.Equal(t, "form", tk)
+
+	// only form body, not query.
+	_, err = FormExtractor("token").ExtractToken(newRequest("application/json"))
+	require.ErrorIs(t, err, ErrMissingValue)
+	r := makeTestRequest("GET", "/", nil, nil, url.Values{"token": {"query"}})
+	_, err = FormExtractor("token").ExtractToken(r)
+	require.ErrorIs(t, err, ErrMissingValue)
+}
+
+func TestParamExtractor(t *testing.T) {
+	r := makeTestRequest("GET", "/", nil, nil, nil)
+	_, err := ParamExtractor("token").ExtractToken(r)
+	require.ErrorIs(t, err, ErrMissingValue)
+
+	r = r.WithContext(NewParamsContext(r.Context(), gin.Params{{Key: "token", Value: "foo"}}))
+	tk, err := ParamExtractor("token").ExtractToken(r)
+	require.NoError(t, err)
+	require.Equal(t, "foo", tk)
+}
+
 func makeTestRequest(method, path string, headers, cookie map[string]string, urlArgs url.Values) *http.Request {
 	r, _ := http.NewRequest(method, fmt.Sprintf("%v?%v", path, urlArgs.Encode()), nil) // nolint: noctx
 	for k, v := range headers {
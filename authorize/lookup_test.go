@@ -1,9 +1,13 @@
 package authorize
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
 
@@ -147,6 +151,61 @@ func TestLookup(t *testing.T) {
 	}
 }
 
+func TestParseLookup(t *testing.T) {
+	_, err := ParseLookup("")
+	require.NoError(t, err)
+	_, err = ParseLookup("header:Authorization:Bearer,query:token,form:token,cookie:token,param:token,basic:password,websocket:access_token")
+	require.NoError(t, err)
+
+	for _, lookup := range []string{
+		"xx",
+		"header:Authorization:Bearer,xxxx",
+		"unknown:token",
+		"query:token:Bearer",
+		"basic:token",
+	} {
+		_, err = ParseLookup(lookup)
+		require.ErrorIs(t, err, ErrInvalidLookup, lookup)
+	}
+	_, err = New[string](Config{Lookup: "params:token", Key: []byte("testSecretKey")})
+	require.ErrorIs(t, err, ErrInvalidLookup)
+}
+
+func TestLookup_QueryAndForm(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/?a=query", strings.NewReader("b=form"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+	for _, tt := range []struct {
+		lookup string
+		token  string
+		err    error
+	}{
+		{"query:a", "query", nil},
+		{"query:b", "form", nil},
+		{"form:b", "form", nil},
+		{"form:a", "", ErrMissingValue},
+	} {
+		tk, err := NewLookup(tt.lookup).ExtractToken(newRequest())
+		require.ErrorIs(t, err, tt.err, tt.lookup)
+		require.Equal(t, tt.token, tk, tt.lookup)
+	}
+}
+
+func TestLookup_Param(t *testing.T) {
+	auth := newTestAuth(t, Config{Lookup: "param:token"})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/ws/:token", auth.Middleware(), func(c *gin.Context) {})
+	w := testAuthorizeRequest(router, "/ws/"+tk, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = testAuthorizeRequest(router, "/ws/foo", "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
 func TestFrom(t *testing.T) {
 	t.Run("from header", func(t *testing.T) {
 		r := makeTestRequest("GET", "/", map[string]string{"token": "foo"}, nil, nil)
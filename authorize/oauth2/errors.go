@@ -0,0 +1,57 @@
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error the OAuth 2.0 error response (RFC 6749 section 5.2).
+type Error struct {
+	// Status http status code.
+	Status int `json:"-"`
+	// Code the error code.
+	Code string `json:"error"`
+	// Description human-readable description, which is safe to expose to the client.
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+// Is reports whether the target is an Error with the same code, so the
+// predefined errors can be used with errors.Is regardless of the description.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Code == e.Code
+}
+
+// WithDescription returns a copy of the error with the description.
+func (e *Error) WithDescription(description string) *Error {
+	err := *e
+	err.Description = description
+	return &err
+}
+
+// the errors of RFC 6749, the Authenticator returns them to reject the request.
+var (
+	// ErrInvalidRequest the request is missing a required parameter or is otherwise malformed.
+	ErrInvalidRequest = &Error{Status: http.StatusBadRequest, Code: "invalid_request"}
+	// ErrInvalidClient the client authentication failed.
+	ErrInvalidClient = &Error{Status: http.StatusUnauthorized, Code: "invalid_client"}
+	// ErrInvalidGrant the provided authorization grant or refresh token is invalid, expired or revoked.
+	ErrInvalidGrant = &Error{Status: http.StatusBadRequest, Code: "invalid_grant"}
+	// ErrUnauthorizedClient the client is not authorized to use the grant type.
+	ErrUnauthorizedClient = &Error{Status: http.StatusBadRequest, Code: "unauthorized_client"}
+	// ErrUnsupportedGrantType the grant type is not supported.
+	ErrUnsupportedGrantType = &Error{Status: http.StatusBadRequest, Code: "unsupported_grant_type"}
+	// ErrInvalidScope the requested scope is invalid, unknown, or exceeds the scope granted.
+	ErrInvalidScope = &Error{Status: http.StatusBadRequest, Code: "invalid_scope"}
+	// ErrUnsupportedTokenType the token type of the revocation request is not supported, see RFC 7009.
+	ErrUnsupportedTokenType = &Error{Status: http.StatusBadRequest, Code: "unsupported_token_type"}
+	// ErrServerError the server encountered an unexpected condition.
+	ErrServerError = &Error{Status: http.StatusInternalServerError, Code: "server_error"}
+)
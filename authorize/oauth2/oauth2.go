@@ -0,0 +1,382 @@
+// Package oauth2 provides the OAuth 2.0 authorization server endpoints built on authorize.Auth,
+// the token endpoint (RFC 6749), the revocation endpoint (RFC 7009) and the introspection endpoint (RFC 7662).
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/things-go/gin-contrib/authorize"
+)
+
+// the grant types.
+const (
+	GrantTypePassword          = "password"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// Authenticator checks the credentials, and returns the claims to be issued.
+// return the Error of this package, such as ErrInvalidClient, ErrInvalidGrant, to reject the request,
+// other errors respond ErrServerError.
+type Authenticator[T any] interface {
+	// AuthenticateClient authenticates the client, clientSecret is empty for the public client.
+	AuthenticateClient(ctx context.Context, clientID, clientSecret string) error
+	// AuthenticateUser authenticates the resource owner of the password grant,
+	// returns the claims with the subject and the granted scope.
+	AuthenticateUser(ctx context.Context, clientID, username, password string, scopes []string) (*authorize.Claims[T], error)
+	// ClientCredentials returns the claims of the client credentials grant, with the granted scope.
+	ClientCredentials(ctx context.Context, clientID string, scopes []string) (*authorize.Claims[T], error)
+}
+
+// TokenResponse the successful response of the token endpoint.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Option is Server option.
+type Option func(*options)
+
+type options struct {
+	grantTypes   []string
+	introspector func(ctx context.Context, clientID string) (bool, error)
+	withMeta     bool
+}
+
+// WithGrantTypes sets the supported grant types, default: password, client_credentials, refresh_token.
+func WithGrantTypes(grantTypes ...string) Option {
+	return func(o *options) {
+		if len(grantTypes) > 0 {
+			o.grantTypes = grantTypes
+		}
+	}
+}
+
+// WithIntrospector sets whether the client can introspect the tokens issued to the other clients,
+// such as an allow-list of the resource servers, a non-nil error responds ErrServerError.
+// default: the client can only introspect the tokens issued to itself.
+func WithIntrospector(fn func(ctx context.Context, clientID string) (bool, error)) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.introspector = fn
+		}
+	}
+}
+
+// WithIntrospectMeta sets whether the introspection response contains the Claims.Meta as the extension field "meta".
+// NOTE: the Meta may be hidden from the client by the encrypted token, only enable it for the trusted callers.
+// default: false
+func WithIntrospectMeta(withMeta bool) Option {
+	return func(o *options) {
+		o.withMeta = withMeta
+	}
+}
+
+// Server the OAuth 2.0 authorization server.
+type Server[T any] struct {
+	auth          *authorize.Auth[T]
+	authenticator Authenticator[T]
+	grantTypes    []string
+	introspector  func(ctx context.Context, clientID string) (bool, error)
+	withMeta      bool
+}
+
+// New new Server, the tokens are minted by auth, the credentials are checked by authenticator.
+// NOTE: the revocation endpoint requires Config.RevocationStore of auth.
+func New[T any](auth *authorize.Auth[T], authenticator Authenticator[T], opts ...Option) *Server[T] {
+	o := &options{
+		grantTypes:   []string{GrantTypePassword, GrantTypeClientCredentials, GrantTypeRefreshToken},
+		introspector: func(context.Context, string) (bool, error) { return false, nil },
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Server[T]{
+		auth:          auth,
+		authenticator: authenticator,
+		grantTypes:    o.grantTypes,
+		introspector:  o.introspector,
+		withMeta:      o.withMeta,
+	}
+}
+
+// Register mounts the endpoints on the router group,
+// POST /token, POST /revoke and POST /introspect.
+func (s *Server[T]) Register(r gin.IRouter) {
+	r.POST("/token", s.TokenHandler())
+	r.POST("/revoke", s.RevokeHandler())
+	r.POST("/introspect", s.IntrospectHandler())
+}
+
+// TokenHandler returns the handler of the token endpoint.
+// the password grant issues an access token and a refresh token, the client credentials grant
+// issues only an access token, the refresh token grant rotates the refresh token.
+// the tokens carry the `client_id` of the client, the refresh token can only be redeemed by it.
+func (s *Server[T]) TokenHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		clientID, err := s.authenticateClient(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		grantType := c.PostForm("grant_type")
+		if grantType == "" {
+			abortWithError(c, ErrInvalidRequest.WithDescription("missing grant_type"))
+			return
+		}
+		if !slices.Contains(s.grantTypes, grantType) {
+			abortWithError(c, ErrUnsupportedGrantType)
+			return
+		}
+		scopes := strings.Fields(c.PostForm("scope"))
+
+		var resp *TokenResponse
+		switch grantType {
+		case GrantTypePassword:
+			username, password := c.PostForm("username"), c.PostForm("password")
+			if username == "" || password == "" {
+				abortWithError(c, ErrInvalidRequest.WithDescription("missing username or password"))
+				return
+			}
+			var claims *authorize.Claims[T]
+
+			claims, err = s.authenticator.AuthenticateUser(ctx, clientID, username, password, scopes)
+			if err == nil {
+				claims.ClientID = clientID
+				resp, err = s.issueTokenPair(ctx, claims)
+			}
+		case GrantTypeClientCredentials:
+			var claims *authorize.Claims[T]
+
+			claims, err = s.authenticator.ClientCredentials(ctx, clientID, scopes)
+			if err == nil {
+				claims.ClientID = clientID
+				resp, err = s.issueToken(claims)
+			}
+		case GrantTypeRefreshToken:
+			refreshToken := c.PostForm("refresh_token")
+			if refreshToken == "" {
+				abortWithError(c, ErrInvalidRequest.WithDescription("missing refresh_token"))
+				return
+			}
+			resp, err = s.refresh(ctx, clientID, refreshToken)
+		default:
+			err = ErrUnsupportedGrantType
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		noStore(c)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// refresh rotates the refresh token, which must be issued to the client (RFC 6749 section 6).
+func (s *Server[T]) refresh(ctx context.Context, clientID, refreshToken string) (*TokenResponse, error) {
+	claims, err := s.auth.ParseRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, invalidGrant(err)
+	}
+	if claims.ClientID != clientID {
+		return nil, ErrInvalidGrant.WithDescription("refresh token was issued to another client")
+	}
+	pair, err := s.auth.Refresh(ctx, refreshToken)
+	if err != nil {
+		return nil, invalidGrant(err)
+	}
+	return newTokenResponse(pair.AccessToken, pair.AccessExpiresAt, pair.RefreshToken, claims.Scope), nil
+}
+
+// invalidGrant returns ErrInvalidGrant with the description of the invalid refresh token,
+// the error which is not caused by the token is returned as it is.
+func invalidGrant(err error) error {
+	e := authorize.NewBearerError(err)
+	if e.Status >= http.StatusInternalServerError {
+		return err
+	}
+	return ErrInvalidGrant.WithDescription(e.Description)
+}
+
+// RevokeHandler returns the handler of the revocation endpoint (RFC 7009), it revokes the access token,
+// or the refresh token with its whole family. it responds 200 even if the token is invalid
+// or issued to another client, and responds unsupported_token_type if RevocationStore is not set.
+func (s *Server[T]) RevokeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, err := s.authenticateClient(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		token := c.PostForm("token")
+		if token == "" {
+			abortWithError(c, ErrInvalidRequest.WithDescription("missing token"))
+			return
+		}
+		ctx := c.Request.Context()
+		claims, refresh := s.parse(ctx, token, c.PostForm("token_type_hint"))
+		if claims != nil && claims.ClientID == clientID {
+			err = s.auth.Revoke(ctx, claims)
+			if err == nil && refresh {
+				err = s.auth.RevokeFamily(ctx, claims)
+			}
+			if errors.Is(err, authorize.ErrMissingRevocationStore) {
+				err = ErrUnsupportedTokenType.WithDescription("revocation is not supported")
+			}
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// IntrospectHandler returns the handler of the introspection endpoint (RFC 7662),
+// the response of the active token contains the claims, the Claims.Meta is only contained if WithIntrospectMeta.
+// the token issued to another client is reported inactive, unless the caller is allowed by WithIntrospector.
+func (s *Server[T]) IntrospectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, err := s.authenticateClient(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		token := c.PostForm("token")
+		if token == "" {
+			abortWithError(c, ErrInvalidRequest.WithDescription("missing token"))
+			return
+		}
+		noStore(c)
+		ctx := c.Request.Context()
+		claims, refresh := s.parse(ctx, token, c.PostForm("token_type_hint"))
+		allowed := claims != nil && claims.ClientID == clientID
+		if claims != nil && !allowed {
+			if allowed, err = s.introspector(ctx, clientID); err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+		if !allowed {
+			c.JSON(http.StatusOK, gin.H{"active": false})
+			return
+		}
+		data, err := json.Marshal(claims)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		resp := map[string]any{}
+		if err = json.Unmarshal(data, &resp); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !s.withMeta {
+			delete(resp, "meta")
+		}
+		resp["active"] = true
+		resp["token_type"] = "Bearer"
+		if refresh {
+			resp["token_type"] = GrantTypeRefreshToken
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// authenticateClient authenticates the client by http basic authentication,
+// or the `client_id` and `client_secret` of the form.
+func (s *Server[T]) authenticateClient(c *gin.Context) (string, error) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" {
+		return "", ErrInvalidClient.WithDescription("missing client credentials")
+	}
+	if err := s.authenticator.AuthenticateClient(c.Request.Context(), clientID, clientSecret); err != nil {
+		return "", err
+	}
+	return clientID, nil
+}
+
+// parse parses the token as the access token or the refresh token, the hint is tried first.
+func (s *Server[T]) parse(ctx context.Context, token, hint string) (claims *authorize.Claims[T], refresh bool) {
+	type parser struct {
+		parse   func(context.Context, string) (*authorize.Claims[T], error)
+		refresh bool
+	}
+	parsers := []parser{
+		{s.auth.ParseTokenWithContext, false},
+		{s.auth.ParseRefreshToken, true},
+	}
+	if hint == GrantTypeRefreshToken {
+		parsers[0], parsers[1] = parsers[1], parsers[0]
+	}
+	for _, p := range parsers {
+		if claims, err := p.parse(ctx, token); err == nil {
+			return claims, p.refresh
+		}
+	}
+	return nil, false
+}
+
+func (s *Server[T]) issueTokenPair(ctx context.Context, claims *authorize.Claims[T]) (*TokenResponse, error) {
+	pair, err := s.auth.GenerateTokenPair(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenResponse(pair.AccessToken, pair.AccessExpiresAt, pair.RefreshToken, claims.Scope), nil
+}
+
+func (s *Server[T]) issueToken(claims *authorize.Claims[T]) (*TokenResponse, error) {
+	if claims.ID == "" {
+		claims.ID = ulid.Make().String()
+	}
+	token, expiresAt, err := s.auth.GenerateToken(claims)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenResponse(token, expiresAt, "", claims.Scope), nil
+}
+
+func newTokenResponse(accessToken string, expiresAt time.Time, refreshToken, scope string) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(expiresAt).Round(time.Second).Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}
+}
+
+func noStore(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+}
+
+// abortWithError responds the Error, other errors respond ErrServerError.
+func abortWithError(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		_ = c.Error(err)
+		e = ErrServerError
+	}
+	if e.Status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oauth2"`)
+	}
+	noStore(c)
+	c.AbortWithStatusJSON(e.Status, e)
+}
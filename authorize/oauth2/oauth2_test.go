@@ -0,0 +1,293 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize"
+	"github.com/things-go/gin-contrib/authorize/introspection"
+	"github.com/things-go/gin-contrib/authorize/revocation/memory"
+)
+
+type testAccount struct {
+	Username string `json:"username"`
+}
+
+type testAuthenticator struct{}
+
+var testClients = map[string]string{"web": "secret", "mobile": "secret2", "rs": "secret3"}
+
+// testIntrospector allows the resource server to introspect all tokens.
+func testIntrospector(_ context.Context, clientID string) (bool, error) { return clientID == "rs", nil }
+
+func (testAuthenticator) AuthenticateClient(_ context.Context, clientID, clientSecret string) error {
+	if secret, ok := testClients[clientID]; !ok || clientSecret != secret {
+		return ErrInvalidClient
+	}
+	return nil
+}
+
+func (testAuthenticator) AuthenticateUser(_ context.Context, _, username, password string, scopes []string) (*authorize.Claims[testAccount], error) {
+	if username != "alice" || password != "password" {
+		return nil, ErrInvalidGrant.WithDescription("invalid username or password")
+	}
+	return &authorize.Claims[testAccount]{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: username},
+		Scope:            strings.Join(scopes, " "),
+		Meta:             testAccount{Username: username},
+	}, nil
+}
+
+func (testAuthenticator) ClientCredentials(_ context.Context, clientID string, scopes []string) (*authorize.Claims[testAccount], error) {
+	if len(scopes) > 0 && scopes[0] == "admin" {
+		return nil, ErrInvalidScope
+	}
+	return &authorize.Claims[testAccount]{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: clientID},
+		Scope:            strings.Join(scopes, " "),
+	}, nil
+}
+
+func newTestServer(t *testing.T, config func(*authorize.Config), opts ...Option) *httptest.Server {
+	store := memory.NewStore(cache.New(time.Hour, time.Minute))
+	cfg := authorize.Config{
+		Timeout:         time.Hour,
+		Key:             []byte("testSecretKey"),
+		RevocationStore: store,
+		FamilyStore:     store,
+	}
+	if config != nil {
+		config(&cfg)
+	}
+	auth, err := authorize.New[testAccount](cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	New[testAccount](auth, testAuthenticator{}, opts...).Register(router.Group("/oauth2"))
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postForm(t *testing.T, srv *httptest.Server, path string, form url.Values, basic bool) (int, map[string]any) {
+	if basic {
+		return postFormAs(t, srv, path, form, "web")
+	}
+	return postFormAs(t, srv, path, form, "")
+}
+
+// postFormAs posts the form with http basic authentication of the client, no authentication if clientID is empty.
+func postFormAs(t *testing.T, srv *httptest.Server, path string, form url.Values, clientID string) (int, map[string]any) {
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, testClients[clientID])
+	}
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body := map[string]any{}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+	return res.StatusCode, body
+}
+
+func TestServer_Token(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	// password grant
+	status, body := postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type": {GrantTypePassword},
+		"username":   {"alice"},
+		"password":   {"password"},
+		"scope":      {"read write"},
+	}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Bearer", body["token_type"])
+	require.Equal(t, "read write", body["scope"])
+	require.InDelta(t, time.Hour.Seconds(), body["expires_in"], 2)
+	require.NotEmpty(t, body["access_token"])
+	refreshToken := body["refresh_token"].(string)
+	require.NotEmpty(t, refreshToken)
+
+	// refresh token grant with client credentials in the form
+	status, body = postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+		"client_id":     {"web"},
+		"client_secret": {"secret"},
+	}, false)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEqual(t, refreshToken, body["refresh_token"])
+	// reused refresh token
+	status, body = postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+	}, true)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid_grant", body["error"])
+
+	// client credentials grant
+	status, body = postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type": {GrantTypeClientCredentials},
+		"scope":      {"read"},
+	}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, body["access_token"])
+	require.NotContains(t, body, "refresh_token")
+
+	for _, tt := range []struct {
+		name   string
+		form   url.Values
+		basic  bool
+		status int
+		code   string
+	}{
+		{"invalid client", url.Values{"grant_type": {GrantTypeClientCredentials}}, false, http.StatusUnauthorized, "invalid_client"},
+		{"missing grant type", url.Values{}, true, http.StatusBadRequest, "invalid_request"},
+		{"unsupported grant type", url.Values{"grant_type": {"authorization_code"}}, true, http.StatusBadRequest, "unsupported_grant_type"},
+		{"invalid password", url.Values{"grant_type": {GrantTypePassword}, "username": {"alice"}, "password": {"x"}}, true, http.StatusBadRequest, "invalid_grant"},
+		{"missing password", url.Values{"grant_type": {GrantTypePassword}, "username": {"alice"}}, true, http.StatusBadRequest, "invalid_request"},
+		{"invalid scope", url.Values{"grant_type": {GrantTypeClientCredentials}, "scope": {"admin"}}, true, http.StatusBadRequest, "invalid_scope"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := postForm(t, srv, "/oauth2/token", tt.form, tt.basic)
+			require.Equal(t, tt.status, status)
+			require.Equal(t, tt.code, body["error"])
+		})
+	}
+}
+
+func TestServer_IntrospectAndRevoke(t *testing.T) {
+	srv := newTestServer(t, nil, WithIntrospector(testIntrospector), WithIntrospectMeta(true))
+	status, body := postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type": {GrantTypePassword},
+		"username":   {"alice"},
+		"password":   {"password"},
+		"scope":      {"read"},
+	}, true)
+	require.Equal(t, http.StatusOK, status)
+	accessToken, refreshToken := body["access_token"].(string), body["refresh_token"].(string)
+
+	// introspected by the resource server with the introspection client
+	client, err := introspection.New[testAccount](introspection.Config[testAccount]{
+		Endpoint:     srv.URL + "/oauth2/introspect",
+		ClientID:     "rs",
+		ClientSecret: "secret3",
+		CacheTTL:     -1,
+	})
+	require.NoError(t, err)
+	claims, err := client.Validate(context.Background(), accessToken)
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+	require.Equal(t, "alice", claims.Meta.Username)
+	require.True(t, claims.HasScopes("read"))
+
+	status, body = postForm(t, srv, "/oauth2/introspect", url.Values{"token": {refreshToken}, "token_type_hint": {GrantTypeRefreshToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, body["active"])
+	require.Equal(t, GrantTypeRefreshToken, body["token_type"])
+	status, body = postForm(t, srv, "/oauth2/introspect", url.Values{"token": {"invalid"}}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, false, body["active"])
+	status, _ = postForm(t, srv, "/oauth2/introspect", url.Values{"token": {accessToken}}, false)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	// revoke access token
+	status, _ = postForm(t, srv, "/oauth2/revoke", url.Values{"token": {accessToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	_, err = client.Validate(context.Background(), accessToken)
+	require.ErrorIs(t, err, introspection.ErrTokenInactive)
+	// invalid token is ok
+	status, _ = postForm(t, srv, "/oauth2/revoke", url.Values{"token": {"invalid"}}, true)
+	require.Equal(t, http.StatusOK, status)
+
+	// revoke refresh token revokes the family
+	status, _ = postForm(t, srv, "/oauth2/revoke", url.Values{"token": {refreshToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	status, body = postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+	}, true)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid_grant", body["error"])
+}
+
+func TestServer_ClientBinding(t *testing.T) {
+	srv := newTestServer(t, nil)
+	status, body := postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type": {GrantTypePassword},
+		"username":   {"alice"},
+		"password":   {"password"},
+	}, true)
+	require.Equal(t, http.StatusOK, status)
+	accessToken, refreshToken := body["access_token"].(string), body["refresh_token"].(string)
+
+	status, body = postForm(t, srv, "/oauth2/introspect", url.Values{"token": {accessToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "web", body["client_id"])
+	require.NotContains(t, body, "meta")
+
+	// another client can not introspect, revoke or refresh it.
+	status, body = postFormAs(t, srv, "/oauth2/introspect", url.Values{"token": {accessToken}}, "mobile")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]any{"active": false}, body)
+	status, body = postFormAs(t, srv, "/oauth2/introspect", url.Values{"token": {accessToken}}, "rs")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]any{"active": false}, body)
+	status, _ = postFormAs(t, srv, "/oauth2/revoke", url.Values{"token": {accessToken}}, "mobile")
+	require.Equal(t, http.StatusOK, status)
+	status, body = postFormAs(t, srv, "/oauth2/token", url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+	}, "mobile")
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid_grant", body["error"])
+
+	// still valid for the client.
+	status, body = postForm(t, srv, "/oauth2/introspect", url.Values{"token": {accessToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, body["active"])
+	status, body = postForm(t, srv, "/oauth2/token", url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+	}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, body["refresh_token"])
+}
+
+func TestServer_RevokeClientCredentials(t *testing.T) {
+	srv := newTestServer(t, nil)
+	status, body := postForm(t, srv, "/oauth2/token", url.Values{"grant_type": {GrantTypeClientCredentials}}, true)
+	require.Equal(t, http.StatusOK, status)
+	accessToken := body["access_token"].(string)
+
+	status, _ = postForm(t, srv, "/oauth2/revoke", url.Values{"token": {accessToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	status, body = postForm(t, srv, "/oauth2/introspect", url.Values{"token": {accessToken}}, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, false, body["active"])
+
+	// without RevocationStore
+	srv = newTestServer(t, func(c *authorize.Config) {
+		c.RevocationStore = nil
+		c.FamilyStore = nil
+	})
+	status, body = postForm(t, srv, "/oauth2/token", url.Values{"grant_type": {GrantTypeClientCredentials}}, true)
+	require.Equal(t, http.StatusOK, status)
+	status, body = postForm(t, srv, "/oauth2/revoke", url.Values{"token": {body["access_token"].(string)}}, true)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "unsupported_token_type", body["error"])
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+)
+
+const (
+	idPrefix            = "jti:"
+	subjectPrefix       = "sub:"
+	familyPrefix        = "fam:"
+	revokedFamilyPrefix = "fam.revoked:"
+	usedPrefix          = "used:"
+)
+
+// Store memory revocation store
+type Store struct {
+	Cache *cache.Cache
+	// mu guards the compare-and-swap of family rotation.
+	mu sync.Mutex
+}
+
+// NewStore new memory revocation store
+func NewStore(c *cache.Cache) *Store {
+	return &Store{Cache: c}
+}
+
+// Revoke implement revocation.Store interface
+func (s *Store) Revoke(_ context.Context, id string, expiresAt time.Time) error {
+	s.Cache.Set(idPrefix+id, struct{}{}, ttl(expiresAt))
+	return nil
+}
+
+// IsRevoked implement revocation.Store interface
+func (s *Store) IsRevoked(_ context.Context, id string) (bool, error) {
+	_, found := s.Cache.Get(idPrefix + id)
+	return found, nil
+}
+
+// RevokeSubject implement revocation.Store interface
+func (s *Store) RevokeSubject(_ context.Context, subject string, before, expiresAt time.Time) error {
+	s.Cache.Set(subjectPrefix+subject, before, ttl(expiresAt))
+	return nil
+}
+
+// RevokedBefore implement revocation.Store interface
+func (s *Store) RevokedBefore(_ context.Context, subject string) (time.Time, error) {
+	val, found := s.Cache.Get(subjectPrefix + subject)
+	if !found {
+		return time.Time{}, nil
+	}
+	before, _ := val.(time.Time)
+	return before, nil
+}
+
+// Issue implement revocation.FamilyStore interface
+func (s *Store) Issue(_ context.Context, family, id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Cache.Set(familyPrefix+family, id, ttl(expiresAt))
+	return nil
+}
+
+// Rotate implement revocation.FamilyStore interface
+func (s *Store) Rotate(_ context.Context, family, old, new string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, found := s.Cache.Get(familyPrefix + family)
+	if !found || current != old {
+		return revocation.ErrReused
+	}
+	s.Cache.Set(familyPrefix+family, new, ttl(expiresAt))
+	return nil
+}
+
+// RevokeFamily implement revocation.FamilyStore interface
+func (s *Store) RevokeFamily(_ context.Context, family string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Cache.Delete(familyPrefix + family)
+	s.Cache.Set(revokedFamilyPrefix+family, struct{}{}, ttl(expiresAt))
+	return nil
+}
+
+// IsFamilyRevoked implement revocation.FamilyStore interface
+func (s *Store) IsFamilyRevoked(_ context.Context, family string) (bool, error) {
+	_, found := s.Cache.Get(revokedFamilyPrefix + family)
+	return found, nil
+}
+
+// Use implement revocation.ReplayStore interface
+func (s *Store) Use(_ context.Context, id string, expiresAt time.Time) (bool, error) {
+	return s.Cache.Add(usedPrefix+id, struct{}{}, ttl(expiresAt)) == nil, nil
+}
+
+// ttl returns the remaining duration until expiresAt, at least one second,
+// so an already expired mark does not become a permanent one.
+func ttl(expiresAt time.Time) time.Duration {
+	return max(time.Until(expiresAt), time.Second)
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+)
+
+func Test_Memory_Revoke(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.New(time.Hour, time.Minute*10))
+
+	revoked, err := store.IsRevoked(ctx, "id")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = store.Revoke(ctx, "id", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	revoked, err = store.IsRevoked(ctx, "id")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// already expired mark
+	err = store.Revoke(ctx, "expired", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	time.Sleep(time.Second + 100*time.Millisecond)
+	revoked, err = store.IsRevoked(ctx, "expired")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func Test_Memory_RevokeSubject(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.New(time.Hour, time.Minute*10))
+
+	before, err := store.RevokedBefore(ctx, "sub")
+	require.NoError(t, err)
+	require.True(t, before.IsZero())
+
+	now := time.Now()
+	err = store.RevokeSubject(ctx, "sub", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	before, err = store.RevokedBefore(ctx, "sub")
+	require.NoError(t, err)
+	require.True(t, now.Equal(before))
+}
+
+func Test_Memory_Family(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.New(time.Hour, time.Minute*10))
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := store.Rotate(ctx, "fam", "1", "2", expiresAt)
+	require.ErrorIs(t, err, revocation.ErrReused)
+
+	err = store.Issue(ctx, "fam", "1", expiresAt)
+	require.NoError(t, err)
+	err = store.Rotate(ctx, "fam", "1", "2", expiresAt)
+	require.NoError(t, err)
+	err = store.Rotate(ctx, "fam", "1", "3", expiresAt)
+	require.ErrorIs(t, err, revocation.ErrReused)
+
+	revoked, err := store.IsFamilyRevoked(ctx, "fam")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	err = store.RevokeFamily(ctx, "fam", expiresAt)
+	require.NoError(t, err)
+	revoked, err = store.IsFamilyRevoked(ctx, "fam")
+	require.NoError(t, err)
+	require.True(t, revoked)
+	err = store.Rotate(ctx, "fam", "2", "3", expiresAt)
+	require.ErrorIs(t, err, revocation.ErrReused)
+}
+
+func Test_Memory_Use(t *testing.T) {
+	ctx := context.Background()
+	var store revocation.ReplayStore = NewStore(cache.New(time.Hour, time.Minute*10))
+
+	first, err := store.Use(ctx, "id", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, first)
+	first, err = store.Use(ctx, "id", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, first)
+}
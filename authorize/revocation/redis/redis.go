@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+)
+
+// DefaultKeyPrefix default revocation key prefix
+const DefaultKeyPrefix = "authorize.revocation:"
+
+// rotateScript replaces the current refresh token id of the family
+// only if it is equal to the old one.
+var rotateScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// Store redis revocation store
+type Store struct {
+	Redisc *redis.Client
+	// Prefix key prefix, default DefaultKeyPrefix
+	Prefix string
+}
+
+// NewStore new redis revocation store
+func NewStore(client *redis.Client) *Store {
+	return &Store{client, DefaultKeyPrefix}
+}
+
+// Revoke implement revocation.Store interface
+func (s *Store) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	return s.Redisc.Set(ctx, s.idKey(id), 1, ttl(expiresAt)).Err()
+}
+
+// IsRevoked implement revocation.Store interface
+func (s *Store) IsRevoked(ctx context.Context, id string) (bool, error) {
+	n, err := s.Redisc.Exists(ctx, s.idKey(id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RevokeSubject implement revocation.Store interface
+func (s *Store) RevokeSubject(ctx context.Context, subject string, before, expiresAt time.Time) error {
+	return s.Redisc.Set(ctx, s.subjectKey(subject), before.UnixMilli(), ttl(expiresAt)).Err()
+}
+
+// RevokedBefore implement revocation.Store interface
+func (s *Store) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	ms, err := s.Redisc.Get(ctx, s.subjectKey(subject)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// Issue implement revocation.FamilyStore interface
+func (s *Store) Issue(ctx context.Context, family, id string, expiresAt time.Time) error {
+	return s.Redisc.Set(ctx, s.familyKey(family), id, ttl(expiresAt)).Err()
+}
+
+// Rotate implement revocation.FamilyStore interface
+func (s *Store) Rotate(ctx context.Context, family, old, new string, expiresAt time.Time) error {
+	ok, err := rotateScript.Run(ctx, s.Redisc,
+		[]string{s.familyKey(family)},
+		old, new, ttl(expiresAt).Milliseconds(),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return revocation.ErrReused
+	}
+	return nil
+}
+
+// RevokeFamily implement revocation.FamilyStore interface
+func (s *Store) RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error {
+	_, err := s.Redisc.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.familyKey(family))
+		pipe.Set(ctx, s.revokedFamilyKey(family), 1, ttl(expiresAt))
+		return nil
+	})
+	return err
+}
+
+// IsFamilyRevoked implement revocation.FamilyStore interface
+func (s *Store) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	n, err := s.Redisc.Exists(ctx, s.revokedFamilyKey(family)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Use implement revocation.ReplayStore interface
+func (s *Store) Use(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	return s.Redisc.SetNX(ctx, s.usedKey(id), 1, ttl(expiresAt)).Result()
+}
+
+func (s *Store) idKey(id string) string { return s.Prefix + "jti:" + id }
+
+func (s *Store) subjectKey(subject string) string { return s.Prefix + "sub:" + subject }
+
+func (s *Store) familyKey(family string) string { return s.Prefix + "fam:" + family }
+
+func (s *Store) revokedFamilyKey(family string) string { return s.Prefix + "fam.revoked:" + family }
+
+func (s *Store) usedKey(id string) string { return s.Prefix + "used:" + id }
+
+// ttl returns the remaining duration until expiresAt, at least one second,
+// so an already expired mark does not become a permanent one.
+func ttl(expiresAt time.Time) time.Duration {
+	return max(time.Until(expiresAt), time.Second)
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/things-go/gin-contrib/authorize/revocation"
+)
+
+func Test_Redis_Revoke(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	store := NewStore(redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	}))
+
+	revoked, err := store.IsRevoked(ctx, "id")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = store.Revoke(ctx, "id", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	revoked, err = store.IsRevoked(ctx, "id")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	mr.FastForward(time.Hour + time.Second)
+	revoked, err = store.IsRevoked(ctx, "id")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func Test_Redis_RevokeSubject(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	store := NewStore(redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	}))
+
+	before, err := store.RevokedBefore(ctx, "sub")
+	require.NoError(t, err)
+	require.True(t, before.IsZero())
+
+	now := time.Now()
+	err = store.RevokeSubject(ctx, "sub", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	before, err = store.RevokedBefore(ctx, "sub")
+	require.NoError(t, err)
+	require.Equal(t, now.UnixMilli(), before.UnixMilli())
+
+	mr.FastForward(time.Hour + time.Second)
+	before, err = store.RevokedBefore(ctx, "sub")
+	require.NoError(t, err)
+	require.True(t, before.IsZero())
+}
+
+func Test_Redis_Family(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	store := NewStore(redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	}))
+	expiresAt := time.Now().Add(time.Hour)
+
+	err = store.Rotate(ctx, "fam", "1", "2", expiresAt)
+	require.ErrorIs(t, err, revocation.ErrReused)
+
+	err = store.Issue(ctx, "fam", "1", expiresAt)
+	require.NoError(t, err)
+	err = store.Rotate(ctx, "fam", "1", "2", expiresAt)
+	require.NoError(t, err)
+	err = store.Rotate(ctx, "fam", "1", "3", expiresAt)
+	require.ErrorIs(t, err, revocation.ErrReused)
+
+	revoked, err := store.IsFamilyRevoked(ctx, "fam")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	err = store.RevokeFamily(ctx, "fam", expiresAt)
+	require.NoError(t, err)
+	revoked, err = store.IsFamilyRevoked(ctx, "fam")
+	require.NoError(t, err)
+	require.True(t, revoked)
+	err = store.Rotate(ctx, "fam", "2", "3", expiresAt)
+	require.ErrorIs(t, err, revocation.ErrReused)
+}
+
+func Test_Redis_Use(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	var store revocation.ReplayStore = NewStore(redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	}))
+
+	first, err := store.Use(ctx, "id", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, first)
+	first, err = store.Use(ctx, "id", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.False(t, first)
+
+	mr.FastForward(time.Minute + time.Second)
+	first, err = store.Use(ctx, "id", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, first)
+}
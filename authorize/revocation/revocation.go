@@ -0,0 +1,55 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Store is the interface of a token revocation backend.
+// Tokens are keyed by the jwt id (`jti`), subjects by the jwt subject (`sub`).
+type Store interface {
+	// Revoke marks the token id as revoked. The mark only needs to be kept
+	// until expiresAt, after that the token is expired anyway.
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+
+	// IsRevoked reports whether the token id has been revoked.
+	IsRevoked(ctx context.Context, id string) (bool, error)
+
+	// RevokeSubject marks all tokens of the subject issued before `before` as revoked.
+	// The mark only needs to be kept until expiresAt.
+	RevokeSubject(ctx context.Context, subject string, before, expiresAt time.Time) error
+
+	// RevokedBefore returns the time before which all tokens of the subject are revoked.
+	// Returns the zero time if the subject has never been revoked.
+	RevokedBefore(ctx context.Context, subject string) (time.Time, error)
+}
+
+// ErrReused indicates the refresh token is not the current one of its family,
+// it has been rotated already, or the family is unknown.
+var ErrReused = errors.New("revocation: refresh token reused")
+
+// FamilyStore is the interface of a refresh token family backend.
+// A family is the chain of refresh tokens rotated from the same login,
+// only the latest refresh token of a family is valid.
+type FamilyStore interface {
+	// Issue records the refresh token id as the current one of the family.
+	Issue(ctx context.Context, family, id string, expiresAt time.Time) error
+
+	// Rotate atomically replaces the current refresh token id of the family from old to new.
+	// Returns ErrReused if old is not the current one.
+	Rotate(ctx context.Context, family, old, new string, expiresAt time.Time) error
+
+	// RevokeFamily revokes the whole family. The mark only needs to be kept until expiresAt.
+	RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error
+
+	// IsFamilyRevoked reports whether the family has been revoked.
+	IsFamilyRevoked(ctx context.Context, family string) (bool, error)
+}
+
+// ReplayStore is the interface of a one-time use backend, such as the `jti` of DPoP proofs.
+type ReplayStore interface {
+	// Use marks the id as used, reports whether it is the first use.
+	// The mark only needs to be kept until expiresAt.
+	Use(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
@@ -0,0 +1,193 @@
+package authorize
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signer signs the token, the private key can be kept in an external signer, such as KMS or HSM.
+type Signer interface {
+	// Algorithm the JWS algorithm, such as "RS256", "ES256", "EdDSA".
+	Algorithm() string
+	// KeyID the id of the signing key, it is set as the `kid` header of the token if not empty.
+	KeyID() string
+	// Sign signs the JWS signing input, returns the JWS signature (RFC 7518),
+	// NOTE: the ECDSA signature is R || S, not the ASN.1 DER.
+	Sign(data []byte) ([]byte, error)
+}
+
+// keySigner signs with the in-memory key.
+type keySigner struct {
+	method jwt.SigningMethod
+	key    any
+	kid    string
+}
+
+// NewKeySigner new Signer with the in-memory key, key is one of []byte for HMAC,
+// *rsa.PrivateKey, *ecdsa.PrivateKey, *secp256k1.PrivateKey and ed25519.PrivateKey.
+func NewKeySigner(alg string, key any, kid string) (Signer, error) {
+	method, err := getSigningMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkSigningKey(method, key); err != nil {
+		return nil, err
+	}
+	return &keySigner{method: method, key: key, kid: kid}, nil
+}
+
+func (s *keySigner) Algorithm() string { return s.method.Alg() }
+func (s *keySigner) KeyID() string     { return s.kid }
+func (s *keySigner) Sign(data []byte) ([]byte, error) {
+	return s.method.Sign(string(data), s.key)
+}
+
+// Public returns the public key of the asymmetric key, the secret key of HMAC.
+func (s *keySigner) Public() crypto.PublicKey {
+	switch k := s.key.(type) {
+	case interface{ Public() crypto.PublicKey }:
+		return k.Public()
+	case *secp256k1.PrivateKey:
+		return k.PubKey()
+	default:
+		return s.key
+	}
+}
+
+// cryptoSigner signs with crypto.Signer.
+type cryptoSigner struct {
+	method jwt.SigningMethod
+	signer crypto.Signer
+	kid    string
+}
+
+// NewCryptoSigner new Signer with crypto.Signer, which is implemented by most KMS, HSM and PKCS#11 clients,
+// the ASN.1 DER signature of ECDSA is converted to R || S.
+// Possible algorithms: RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, ES256K, EdDSA.
+func NewCryptoSigner(alg string, signer crypto.Signer, kid string) (Signer, error) {
+	method, err := getSigningMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		return nil, fmt.Errorf("%w: %s is not supported by crypto signer", ErrUnsupportedAlgorithm, alg)
+	}
+	if err = checkVerifyingKey(method, signer.Public()); err != nil {
+		return nil, err
+	}
+	return &cryptoSigner{method: method, signer: signer, kid: kid}, nil
+}
+
+func (s *cryptoSigner) Algorithm() string        { return s.method.Alg() }
+func (s *cryptoSigner) KeyID() string            { return s.kid }
+func (s *cryptoSigner) Public() crypto.PublicKey { return s.signer.Public() }
+func (s *cryptoSigner) Sign(data []byte) ([]byte, error) {
+	switch m := s.method.(type) {
+	case *jwt.SigningMethodRSA:
+		return s.signer.Sign(rand.Reader, digest(m.Hash, data), m.Hash)
+	case *jwt.SigningMethodRSAPSS:
+		return s.signer.Sign(rand.Reader, digest(m.Hash, data), &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       m.Hash,
+		})
+	case *jwt.SigningMethodECDSA:
+		der, err := s.signer.Sign(rand.Reader, digest(m.Hash, data), m.Hash)
+		if err != nil {
+			return nil, err
+		}
+		return concatRS(der, (m.CurveBits+7)/8)
+	case *signingMethodES256K:
+		der, err := s.signer.Sign(rand.Reader, digest(crypto.SHA256, data), crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		return concatRS(der, 32)
+	default: // EdDSA
+		return s.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+}
+
+// concatRS converts the ASN.1 DER ECDSA signature to R || S.
+func concatRS(der []byte, keyBytes int) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("invalid ECDSA signature, %w", err)
+	}
+	out := make([]byte, 2*keyBytes)
+	sig.R.FillBytes(out[:keyBytes])
+	sig.S.FillBytes(out[keyBytes:])
+	return out, nil
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// getSigningMethod returns the signing method of the supported algorithm.
+func getSigningMethod(alg string) (jwt.SigningMethod, error) {
+	method := jwt.GetSigningMethod(alg)
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC, *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS,
+		*jwt.SigningMethodECDSA, *signingMethodES256K, *jwt.SigningMethodEd25519:
+		return method, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// checkSigningKey checks the key type of the signing method.
+func checkSigningKey(method jwt.SigningMethod, key any) error {
+	ok := false
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		k, _ := key.([]byte)
+		ok = len(k) > 0
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok = key.(*rsa.PrivateKey)
+	case *jwt.SigningMethodECDSA:
+		k, isEC := key.(*ecdsa.PrivateKey)
+		ok = isEC && k.Curve.Params().BitSize == m.CurveBits
+	case *signingMethodES256K:
+		_, ok = key.(*secp256k1.PrivateKey)
+	case *jwt.SigningMethodEd25519:
+		_, ok = key.(ed25519.PrivateKey)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %T can not be used with %s", ErrInvalidPrivKey, key, method.Alg())
+	}
+	return nil
+}
+
+// checkVerifyingKey checks the key type of the verifying method.
+func checkVerifyingKey(method jwt.SigningMethod, key any) error {
+	ok := false
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		k, _ := key.([]byte)
+		ok = len(k) > 0
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok = key.(*rsa.PublicKey)
+	case *jwt.SigningMethodECDSA:
+		k, isEC := key.(*ecdsa.PublicKey)
+		ok = isEC && k.Curve.Params().BitSize == m.CurveBits
+	case *signingMethodES256K:
+		_, ok = key.(*secp256k1.PublicKey)
+	case *jwt.SigningMethodEd25519:
+		_, ok = key.(ed25519.PublicKey)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %T can not be used with %s", ErrInvalidPubKey, key, method.Alg())
+	}
+	return nil
+}
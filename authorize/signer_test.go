@@ -0,0 +1,118 @@
+package authorize
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// kmsSigner a KMS stand-in which only exposes crypto.Signer.
+type kmsSigner struct {
+	crypto.Signer
+}
+
+func TestCryptoSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		alg    string
+		signer crypto.Signer
+	}{
+		{"RS256", rsaKey},
+		{"RS512", rsaKey},
+		{"ES384", ecKey},
+		{"EdDSA", edKey},
+	} {
+		t.Run(tt.alg, func(t *testing.T) {
+			signer, err := NewCryptoSigner(tt.alg, kmsSigner{tt.signer}, "kms-1")
+			require.NoError(t, err)
+			auth := newTestAuth(t, Config{Signer: signer})
+
+			tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+			require.NoError(t, err)
+			claims, err := auth.ParseToken(tk)
+			require.NoError(t, err)
+			require.Equal(t, "alice", claims.Subject)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(tk, &jwt.RegisteredClaims{})
+			require.NoError(t, err)
+			require.Equal(t, tt.alg, parsed.Header["alg"])
+			require.Equal(t, "kms-1", parsed.Header["kid"])
+
+			// verified by the public key of the crypto signer with jwt.
+			_, err = jwt.Parse(tk, func(*jwt.Token) (any, error) { return tt.signer.Public(), nil })
+			require.NoError(t, err)
+		})
+	}
+
+	_, err = NewCryptoSigner("ES256", ecKey, "")
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+	_, err = NewCryptoSigner("HS256", ecKey, "")
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	_, err = NewCryptoSigner("none", ecKey, "")
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+// remoteSigner a Signer without public key.
+type remoteSigner struct {
+	Signer
+}
+
+func TestAuth_Signer(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := NewKeySigner("ES256", ecKey, "")
+	require.NoError(t, err)
+
+	_, err = New[*testAccount](Config{Signer: remoteSigner{signer}})
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+
+	auth, err := New[*testAccount](Config{Timeout: time.Hour, Signer: remoteSigner{signer}, PublicKey: &ecKey.PublicKey})
+	require.NoError(t, err)
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	_, err = auth.ParseToken(tk)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	auth, err = New[*testAccount](Config{Timeout: time.Hour, Signer: remoteSigner{signer}, PublicKey: &otherKey.PublicKey})
+	require.NoError(t, err)
+	tk, _, err = auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	_, err = auth.ParseToken(tk)
+	require.ErrorIs(t, err, ErrTokenSignatureInvalid)
+}
+
+func TestAuth_PrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	auth := newTestAuth(t, Config{Algorithm: "RS384", PrivateKey: rsaKey, KeyID: "k1"})
+	tk, _, err := auth.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	_, err = auth.ParseToken(tk)
+	require.NoError(t, err)
+
+	_, err = New[*testAccount](Config{Algorithm: "RS256", PrivateKey: ecKey})
+	require.ErrorIs(t, err, ErrInvalidPrivKey)
+	_, err = New[*testAccount](Config{Algorithm: "ES256", PrivateKey: ecKey, PublicKey: &rsaKey.PublicKey})
+	require.ErrorIs(t, err, ErrInvalidPubKey)
+	_, err = New[*testAccount](Config{Algorithm: "ES512", PrivateKey: ecKey})
+	require.ErrorIs(t, err, ErrInvalidPrivKey)
+}
@@ -0,0 +1,228 @@
+package authorize
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantConfig the Config of a tenant.
+type TenantConfig struct {
+	// ID the unique id of the tenant.
+	ID string
+	// Config the config of the tenant, its Lookup is ignored, see MultiTenantConfig.Lookup.
+	Config Config
+}
+
+// TenantProvider provides the tenants, such as from the database or the config center.
+type TenantProvider interface {
+	Tenants(ctx context.Context) ([]TenantConfig, error)
+}
+
+// TenantProviderFunc is an adapter to allow the use of ordinary functions as TenantProvider.
+type TenantProviderFunc func(ctx context.Context) ([]TenantConfig, error)
+
+// Tenants calls f(ctx).
+func (f TenantProviderFunc) Tenants(ctx context.Context) ([]TenantConfig, error) { return f(ctx) }
+
+// TenantResolver resolves the tenant id from the request attribute, such as the host or a header.
+type TenantResolver func(r *http.Request) (string, bool)
+
+// TenantFromHost returns a TenantResolver which resolves the tenant id from the host without port.
+func TenantFromHost() TenantResolver {
+	return func(r *http.Request) (string, bool) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		return host, host != ""
+	}
+}
+
+// TenantFromHeader returns a TenantResolver which resolves the tenant id from the header.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) (string, bool) {
+		id := r.Header.Get(name)
+		return id, id != ""
+	}
+}
+
+// MultiTenantConfig the config of MultiTenant.
+type MultiTenantConfig struct {
+	// Provider provides the tenants.
+	// Required.
+	Provider TenantProvider
+	// Resolver resolves the tenant id from the request, it takes precedence over the token.
+	// Optional, if it is nil or fails, the tenant is resolved by the `kid` header or the `iss` claim of the token.
+	Resolver TenantResolver
+	// Lookup used to extract token from the http request, see Config.Lookup.
+	// Optional, Default value "header:Authorization:Bearer".
+	Lookup string
+}
+
+// tenantSet the immutable set of tenants, which is swapped as a whole.
+type tenantSet[T any] struct {
+	byID     map[string]*Auth[T]
+	byKeyID  map[string]string
+	byIssuer map[string]string
+}
+
+// MultiTenant verifies the token with the key and issuer of the resolved tenant,
+// the tenants can be hot swapped by Reload. it implements Validator.
+type MultiTenant[T any] struct {
+	provider TenantProvider
+	resolver TenantResolver
+	lookup   *Lookup
+	tenants  atomic.Pointer[tenantSet[T]]
+}
+
+// NewMultiTenant new MultiTenant with MultiTenantConfig, the tenants are loaded from the provider.
+func NewMultiTenant[T any](ctx context.Context, c MultiTenantConfig) (*MultiTenant[T], error) {
+	if c.Provider == nil {
+		return nil, fmt.Errorf("authorize: tenant provider is required")
+	}
+	lookup, err := ParseLookup(c.Lookup)
+	if err != nil {
+		return nil, err
+	}
+	m := &MultiTenant[T]{
+		provider: c.Provider,
+		resolver: c.Resolver,
+		lookup:   lookup,
+	}
+	if err = m.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload loads the tenants from the provider and swaps them atomically,
+// if any tenant is invalid, the current tenants are kept and the error is returned.
+func (m *MultiTenant[T]) Reload(ctx context.Context) error {
+	tenants, err := m.provider.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	set := &tenantSet[T]{
+		byID:     make(map[string]*Auth[T], len(tenants)),
+		byKeyID:  make(map[string]string),
+		byIssuer: make(map[string]string),
+	}
+	for _, tenant := range tenants {
+		if _, ok := set.byID[tenant.ID]; ok || tenant.ID == "" {
+			return fmt.Errorf("authorize: tenant %q is empty or duplicated", tenant.ID)
+		}
+		cfg := tenant.Config
+		cfg.Lookup = ""
+		auth, err := New[T](cfg)
+		if err != nil {
+			return fmt.Errorf("authorize: tenant %q, %w", tenant.ID, err)
+		}
+		set.byID[tenant.ID] = auth
+		if kid := auth.signer.KeyID(); kid != "" {
+			if err = indexTenant(set.byKeyID, kid, tenant.ID); err != nil {
+				return err
+			}
+		}
+		issuers := cfg.ValidIssuers
+		if cfg.Issuer != "" {
+			issuers = append([]string{cfg.Issuer}, issuers...)
+		}
+		for _, iss := range issuers {
+			if err = indexTenant(set.byIssuer, iss, tenant.ID); err != nil {
+				return err
+			}
+		}
+	}
+	m.tenants.Store(set)
+	return nil
+}
+
+// Tenant returns the Auth of the tenant.
+func (m *MultiTenant[T]) Tenant(id string) (*Auth[T], bool) {
+	auth, ok := m.tenants.Load().byID[id]
+	return auth, ok
+}
+
+// Validate implement Validator interface, the tenant is the one in the context,
+// otherwise resolved by the `kid` header or the `iss` claim of the token.
+func (m *MultiTenant[T]) Validate(ctx context.Context, token string) (*Claims[T], error) {
+	id, ok := TenantFromContext(ctx)
+	if !ok {
+		var err error
+
+		id, err = m.ResolveToken(token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	auth, ok := m.Tenant(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, id)
+	}
+	return auth.Validate(ctx, token)
+}
+
+// ResolveToken resolves the tenant id by the `kid` header or the `iss` claim of the token,
+// the token is not verified. the encrypted token can only be resolved by the request.
+func (m *MultiTenant[T]) ResolveToken(token string) (string, error) {
+	set := m.tenants.Load()
+	tk, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnknownTenant, err)
+	}
+	if kid, _ := tk.Header["kid"].(string); kid != "" {
+		if id, ok := set.byKeyID[kid]; ok {
+			return id, nil
+		}
+	}
+	if iss, _ := tk.Claims.GetIssuer(); iss != "" {
+		if id, ok := set.byIssuer[iss]; ok {
+			return id, nil
+		}
+	}
+	return "", ErrUnknownTenant
+}
+
+// Middleware returns a middleware which authenticates the request with the resolved tenant,
+// and puts the tenant id into the context, use TenantFromContext to retrieve it.
+// NOTE: WithRenewal is not supported.
+func (m *MultiTenant[T]) Middleware(opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts...)
+	next := NewMiddleware[T](m.lookup, m, opts...)
+	return func(c *gin.Context) {
+		if !o.skip(c) {
+			if id, ok := m.resolve(c); ok {
+				c.Request = c.Request.WithContext(NewTenantContext(c.Request.Context(), id))
+			}
+		}
+		next(c)
+	}
+}
+
+func (m *MultiTenant[T]) resolve(c *gin.Context) (string, bool) {
+	if m.resolver != nil {
+		if id, ok := m.resolver(c.Request); ok {
+			return id, true
+		}
+	}
+	token, _, err := m.lookup.ExtractTokenFromGin(c)
+	if err != nil {
+		return "", false
+	}
+	id, err := m.ResolveToken(token)
+	return id, err == nil
+}
+
+func indexTenant(index map[string]string, key, id string) error {
+	if other, ok := index[key]; ok && other != id {
+		return fmt.Errorf("authorize: %q is shared by tenant %q and %q", key, other, id)
+	}
+	index[key] = id
+	return nil
+}
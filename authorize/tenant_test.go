@@ -0,0 +1,133 @@
+package authorize
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type testTenantProvider struct {
+	mu      sync.Mutex
+	tenants []TenantConfig
+	err     error
+}
+
+func (p *testTenantProvider) Tenants(context.Context) ([]TenantConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tenants, p.err
+}
+
+func (p *testTenantProvider) set(tenants []TenantConfig, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tenants, p.err = tenants, err
+}
+
+func newTestTenants() []TenantConfig {
+	return []TenantConfig{
+		{ID: "acme", Config: Config{Timeout: time.Hour, Key: []byte("acmeSecretKey"), Issuer: "https://acme.example.com"}},
+		{ID: "globex", Config: Config{Timeout: time.Hour, Key: []byte("globexSecretKey"), Issuer: "https://globex.example.com", KeyID: "globex-1"}},
+	}
+}
+
+func TestMultiTenant(t *testing.T) {
+	provider := &testTenantProvider{tenants: newTestTenants()}
+	mt, err := NewMultiTenant[*testAccount](context.Background(), MultiTenantConfig{
+		Provider: provider,
+		Resolver: TenantFromHeader("X-Tenant"),
+	})
+	require.NoError(t, err)
+
+	acme, ok := mt.Tenant("acme")
+	require.True(t, ok)
+	globex, ok := mt.Tenant("globex")
+	require.True(t, ok)
+	acmeToken, _, err := acme.GenerateToken(newTestClaims("1", "alice"))
+	require.NoError(t, err)
+	globexToken, _, err := globex.GenerateToken(newTestClaims("2", "bob"))
+	require.NoError(t, err)
+
+	id, err := mt.ResolveToken(acmeToken)
+	require.NoError(t, err)
+	require.Equal(t, "acme", id)
+	id, err = mt.ResolveToken(globexToken)
+	require.NoError(t, err)
+	require.Equal(t, "globex", id)
+	_, err = mt.ResolveToken("invalid")
+	require.ErrorIs(t, err, ErrUnknownTenant)
+
+	claims, err := mt.Validate(context.Background(), globexToken)
+	require.NoError(t, err)
+	require.Equal(t, "bob", claims.Subject)
+	// the tenant in context takes precedence.
+	_, err = mt.Validate(NewTenantContext(context.Background(), "acme"), globexToken)
+	require.ErrorIs(t, err, ErrTokenSignatureInvalid)
+
+	router := gin.New()
+	router.GET("/", mt.Middleware(), func(c *gin.Context) {
+		id, ok := TenantFromContext(c.Request.Context())
+		require.True(t, ok)
+		c.String(http.StatusOK, id)
+	})
+	request := func(token, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(acmeToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "acme", w.Body.String())
+	w = request(acmeToken, "acme")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = request(acmeToken, "globex")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = request(acmeToken, "initech")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// invalid tenants are rejected, the current tenants are kept.
+	provider.set(append(newTestTenants(), TenantConfig{ID: "acme", Config: Config{Key: []byte("x")}}), nil)
+	require.Error(t, mt.Reload(context.Background()))
+	provider.set(nil, errors.New("provider failure"))
+	require.Error(t, mt.Reload(context.Background()))
+	w = request(acmeToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// hot swap, acme is removed.
+	provider.set(newTestTenants()[1:], nil)
+	require.NoError(t, mt.Reload(context.Background()))
+	w = request(acmeToken, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = request(globexToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "globex", w.Body.String())
+}
+
+func TestMultiTenant_SharedIssuer(t *testing.T) {
+	tenants := newTestTenants()
+	tenants[1].Config.Issuer = tenants[0].Config.Issuer
+	_, err := NewMultiTenant[*testAccount](context.Background(), MultiTenantConfig{
+		Provider: TenantProviderFunc(func(context.Context) ([]TenantConfig, error) { return tenants, nil }),
+	})
+	require.Error(t, err)
+}
+
+func TestTenantFromHost(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://acme.example.com:8080/", nil)
+	id, ok := TenantFromHost()(req)
+	require.True(t, ok)
+	require.Equal(t, "acme.example.com", id)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/oklog/ulid/v2"
 )
 
 func parseRSAPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
@@ -75,3 +76,36 @@ func parseEdPublicKey(publicKey string) (crypto.PublicKey, error) {
 	}
 	return jwt.ParseEdPublicKeyFromPEM(pub)
 }
+
+// parsePrivKey parses the legacy Config.PrivKey of the signing method.
+func parsePrivKey(method jwt.SigningMethod, privateKey string) (crypto.PrivateKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return parseRSAPrivateKey(privateKey)
+	case *jwt.SigningMethodECDSA:
+		return parseECPrivateKey(privateKey)
+	case *jwt.SigningMethodEd25519:
+		return parseEdPrivateKey(privateKey)
+	default:
+		return nil, ErrInvalidPrivKey
+	}
+}
+
+// parsePubKey parses the legacy Config.PubKey of the signing method.
+func parsePubKey(method jwt.SigningMethod, publicKey string) (crypto.PublicKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return parseRSAPublicKey(publicKey)
+	case *jwt.SigningMethodECDSA:
+		return parseECPublicKey(publicKey)
+	case *jwt.SigningMethodEd25519:
+		return parseEdPublicKey(publicKey)
+	default:
+		return nil, ErrInvalidPubKey
+	}
+}
+
+// newId returns a new unique token id, which use ulid.
+func newId() string {
+	return ulid.Make().String()
+}
@@ -0,0 +1,30 @@
+package authorize
+
+import (
+	"context"
+	"time"
+)
+
+// Validator validates the access token and returns its claims, Middleware uses it to authenticate the request.
+// Auth implements it for JWT, see package introspection for opaque token.
+type Validator[T any] interface {
+	Validate(ctx context.Context, token string) (*Claims[T], error)
+}
+
+// ValidatorFunc is an adapter to allow the use of ordinary functions as Validator.
+type ValidatorFunc[T any] func(ctx context.Context, token string) (*Claims[T], error)
+
+// Validate calls f(ctx, token).
+func (f ValidatorFunc[T]) Validate(ctx context.Context, token string) (*Claims[T], error) {
+	return f(ctx, token)
+}
+
+// renewer is implemented by the Validator which can renew the token, such as Auth.
+type renewer[T any] interface {
+	Renew(claims *Claims[T]) (string, time.Time, error)
+}
+
+// Validate validates the access token, it is the same as ParseTokenWithContext.
+func (a *Auth[T]) Validate(ctx context.Context, token string) (*Claims[T], error) {
+	return a.ParseTokenWithContext(ctx, token)
+}
@@ -34,9 +34,7 @@ func main() {
 			authorize.WithSkip(func(c *gin.Context) bool {
 				return c.Request.URL.Path == "/login"
 			}),
-			authorize.WithUnauthorizedFallback(func(c *gin.Context, err error) {
-				c.String(http.StatusUnauthorized, err.Error())
-			}),
+			authorize.WithRealm("gin-contrib"),
 		))
 	// curl -v http://127.0.0.1:8080/login -X 'POST' \
 	//   --header 'Accept: */*' \